@@ -4,81 +4,969 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
 
 	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/config"
+	"github.com/revolvingcow/csfmt/diff"
+	"github.com/revolvingcow/csfmt/git"
+	"github.com/revolvingcow/csfmt/ignore"
+	"github.com/revolvingcow/csfmt/lsp"
+	"github.com/revolvingcow/csfmt/project"
 	"github.com/revolvingcow/csfmt/rules"
 )
 
+const (
+	// maxPasses is the most times the rules are applied to a single file.
+	maxPasses = 10
+
+	// exitDiffers is the exit status when listing files and at least one differs.
+	exitDiffers = 3
+
+	// hookMarker marks a hook as written by csfmt.
+	hookMarker = "# Installed by csfmt hook install"
+
+	// exitFailed is the exit status when at least one file could not be processed.
+	exitFailed = 2
+)
+
 var (
-	flagWrite = flag.Bool("w", false, "write changes to file")
+	flagWrite            = flag.Bool("w", false, "write changes to file")
+	flagAssumeFilename   = flag.String("assume-filename", "", "path to assume for source read from standard input")
+	flagList             = flag.Bool("l", false, "list files whose formatting differs")
+	flagDiff             = flag.Bool("d", false, "display diffs instead of rewriting files")
+	flagCheck            = flag.Bool("check", false, "report violations without changing files")
+	flagOptions          = optionsFlag("option", "set an option of a rule as RULE.OPTION=VALUE")
+	flagJobs             = flag.Int("j", runtime.GOMAXPROCS(0), "number of files to process at once")
+	flagChanged          = flag.Bool("changed", false, "only format files changed since the last commit")
+	flagStaged           = flag.Bool("staged", false, "only format files staged for the next commit")
+	flagSince            = flag.String("since", "", "only format files changed since the branch left the `revision`")
+	flagLines            = linesFlag("lines", "only format the `start:end` lines of a single file")
+	flagDiffOnly         = flag.Bool("diff-only", false, "only format the lines changed in git")
+	flagGitignore        = flag.Bool("gitignore", false, "also leave out files ignored by .gitignore")
+	flagIncludeGenerated = flag.Bool("include-generated", false, "format generated files too")
+	flagVerifyIdempotent = flag.Bool("verify-idempotent", false, "fail when formatting the result again changes it")
 )
 
 func main() {
 	flag.Parse()
-	sourceFiles := []csfmt.SourceFile{}
 
-	// Determine what files to format
-	argc := len(os.Args)
-	if argc < 2 {
+	if err := rules.Validate(rules.Library); err != nil {
+		log.Fatalln(err)
+	}
+	configs := config.NewLoader(rules.Lookup)
+	configs.EditorConfig = rules.EditorConfig
+	configs.Overrides = flagOptions.config()
+	if flag.NArg() == 1 && flag.Arg(0) == "rules" {
+		c, err := configs.Load(config.FileName)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if err := listRules(os.Stdout, rules.Library, c); err != nil {
+			log.Fatalln(err)
+		}
 		return
-	} else if argc == 2 && os.Args[1] == "..." {
-		// Walk the file structure from the current working directory
-		cwd, err := os.Getwd()
+	}
+	if flag.NArg() >= 1 && flag.Arg(0) == "hook" {
+		if flag.NArg() < 2 {
+			log.Fatalln("expected hook install or hook run")
+		}
+		// Flags may come after the subcommand too
+		command := flag.Arg(1)
+		flag.CommandLine.Parse(flag.Args()[2:])
+		switch command {
+		case "install":
+			path, err := installHook(".")
+			if err != nil {
+				log.Fatalln(err)
+			}
+			log.Printf("Installed %s\n", path)
+		case "run":
+			os.Exit(runHook(configs))
+		default:
+			log.Fatalf("unknown hook command %q\n", command)
+		}
+		return
+	}
+	if flag.NArg() == 1 && flag.Arg(0) == "lsp" {
+		if err := languageServer().Serve(os.Stdin, os.Stdout); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	// Determine what files to format
+	args := flag.Args()
+	argc := len(args)
+	if *flagChanged || *flagStaged || *flagSince != "" || *flagDiffOnly {
+		if (*flagChanged && *flagStaged) || (*flagSince != "" && (*flagChanged || *flagStaged)) {
+			log.Fatalln("only one of -changed, -staged and -since may be given")
+		}
+		paths := args
+		if argc == 1 && args[0] == "..." {
+			paths = nil
+		}
+
+		var changed []string
+		var err error
+		switch {
+		case *flagStaged:
+			changed, err = git.Staged(".", paths...)
+		case *flagSince != "":
+			changed, err = git.Since(".", *flagSince, paths...)
+		default:
+			changed, err = git.Changed(".", paths...)
+		}
 		if err != nil {
+			log.Fatalln(err)
+		}
+		if len(changed) == 0 {
+			log.Println("No changed files")
 			return
 		}
+		args, argc = changed, len(changed)
+	}
+	if len(*flagLines) > 0 && (*flagDiffOnly || argc > 1 || (argc == 1 && (args[0] == "..." || (&csfmt.SourceFile{Path: args[0]}).IsDir()))) {
+		log.Fatalln("-lines only applies to a single file and not alongside -diff-only")
+	}
+	if argc < 1 {
+		status, err := formatStandardInput(os.Stdin, os.Stdout, configs)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		os.Exit(status)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	ignored, err := ignoredBy(cwd)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if argc == 1 && args[0] == "..." {
+		// Walk the file structure from the current working directory
+		args = []string{cwd}
+	}
 
+	// Assuming multiple files were given
+	found := []csfmt.SourceFile{}
+	for _, a := range args {
 		s := csfmt.SourceFile{
-			Path: cwd,
+			Path: a,
+		}
+
+		if s.Exists() {
+			if s.IsDir() {
+				for sourceFile := range s.Walk(ignored) {
+					found = append(found, sourceFile)
+				}
+			} else if s.IsDotNet() && !ignored(s.Path, false) {
+				found = append(found, s)
+			} else if project.IsProject(s.Path) {
+				compiled, missing, err := project.Files(s.Path)
+				if err != nil {
+					log.Fatalln(err)
+				}
+				for _, path := range missing {
+					log.Printf("%s: skipping %s which does not exist", s.Path, path)
+				}
+				for _, path := range compiled {
+					sourceFile := csfmt.SourceFile{Path: path}
+					if sourceFile.IsDotNet() && !ignored(path, false) {
+						found = append(found, sourceFile)
+					}
+				}
+			}
+		}
+	}
+	if err := configure(found, configs); err != nil {
+		log.Println(err)
+		os.Exit(exitFailed)
+	}
+
+	files := make(chan csfmt.SourceFile)
+	go func() {
+		defer close(files)
+		for _, s := range found {
+			files <- s
+		}
+	}()
+	count, modified, failed := 0, 0, 0
+	violations, failures := 0, 0
+	skipped := map[string]int{}
+	applied := map[string]bool{}
+	results := processInOrder(files, *flagJobs, func(s csfmt.SourceFile) result {
+		return process(s, configs)
+	})
+	for r := range results {
+		if r.skipped != "" {
+			skipped[r.skipped]++
+			continue
+		}
+		count++
+		if r.err != nil {
+			log.Println(r.err)
+			failed++
+			continue
+		}
+		for _, id := range r.applied {
+			applied[id] = true
+		}
+
+		if *flagCheck {
+			if len(r.diagnostics) > 0 {
+				modified++
+				violations += len(r.diagnostics)
+			}
+			for _, diagnostic := range r.diagnostics {
+				if diagnostic.Severity == csfmt.SeverityError {
+					failures++
+				}
+				fmt.Println(diagnostic)
+			}
+			continue
+		}
+
+		s, original, contents := r.file, r.original, r.formatted
+		if r.reencoded || !bytes.Equal(original, contents) {
+			modified++
+			if *flagList {
+				fmt.Println(s.Path)
+			}
+			if ending := normalizedLineEndings(original, contents); ending != "" {
+				log.Printf("%s: mixed line endings changed to %s\n", s.Path, ending)
+			}
 		}
 
-		for sourceFile := range s.Walk() {
-			sourceFiles = append(sourceFiles, sourceFile)
+		if *flagDiff {
+			os.Stdout.Write(diff.Unified(s.Path+".orig", s.Path, original, contents, 3))
+		} else if !*flagWrite && !*flagList {
+			if err := writeFormatted(os.Stdout, s, contents); err != nil {
+				log.Println(err)
+				failed++
+			}
 		}
+	}
+	if *flagCheck {
+		log.Printf("Found %d violations in %d of %d files using %d rules\n", violations, modified, count, len(applied))
 	} else {
-		// Assuming multiple files were given
-		for _, a := range os.Args[1:] {
-			s := csfmt.SourceFile{
-				Path: a,
+		log.Printf("Modified %d of %d files using %d rules\n", modified, count, len(applied))
+	}
+	if len(skipped) > 0 {
+		total := 0
+		reasons := []string{}
+		for reason, n := range skipped {
+			total += n
+			reasons = append(reasons, fmt.Sprintf("%d %s", n, reason))
+		}
+		sort.Strings(reasons)
+		log.Printf("Skipped %d generated files (%s)\n", total, strings.Join(reasons, ", "))
+	}
+	if failed > 0 {
+		log.Printf("Failed to process %d of %d files\n", failed, count)
+		os.Exit(exitFailed)
+	}
+	if *flagList && modified > 0 {
+		os.Exit(exitDiffers)
+	}
+	if failures > 0 {
+		os.Exit(1)
+	}
+}
+
+// pass applies each of the rules once to the contents of the file found at
+// path, within the ranges when there are any, returning where the ranges
+// ended up and the identifiers of the rules which changed the contents.
+// Changes to the code are reported by where they are found in the original
+// contents of the file.
+func pass(path string, original, contents []byte, ranges []csfmt.Range, queuedRules []*csfmt.Rule) ([]byte, []csfmt.Range, []string, error) {
+	changed := []string{}
+	for _, rule := range queuedRules {
+		formatted, moved, err := rule.FormatWithin(path, contents, ranges)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if !bytes.Equal(contents, formatted) {
+			if err := csfmt.SameCodeFrom(original, contents, formatted); err != nil {
+				return nil, nil, nil, fmt.Errorf("%s would change the code: %s", rule.ID, err)
 			}
+			changed = append(changed, rule.ID)
+		}
+		contents, ranges = formatted, moved
+	}
+	return contents, ranges, changed, nil
+}
 
-			if s.Exists() {
-				if s.IsDir() {
-					for sourceFile := range s.Walk() {
-						sourceFiles = append(sourceFiles, sourceFile)
-					}
-				} else if s.IsDotNet() {
-					sourceFiles = append(sourceFiles, s)
+// format applies the rules to the contents of the file found at path until
+// they no longer change anything, returning where the ranges ended up.
+func format(path string, contents []byte, ranges []csfmt.Range, queuedRules []*csfmt.Rule) ([]byte, []csfmt.Range, error) {
+	original := contents
+	seen := map[string]bool{string(contents): true}
+	for i := 0; i < maxPasses; i++ {
+		formatted, moved, changed, err := pass(path, original, contents, ranges, queuedRules)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(changed) == 0 {
+			return formatted, moved, nil
+		}
+		if seen[string(formatted)] {
+			return nil, nil, fmt.Errorf("rules keep undoing each other: %s", strings.Join(changed, ", "))
+		}
+		seen[string(formatted)] = true
+		contents, ranges = formatted, moved
+	}
+
+	_, _, changed, err := pass(path, original, contents, ranges, queuedRules)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, nil, fmt.Errorf("rules still changing after %d passes: %s", maxPasses, strings.Join(changed, ", "))
+}
+
+// configure loads the configuration of every file and sets up the rules it
+// enables, returning the first mistake found in any of them.
+func configure(files []csfmt.SourceFile, configs *config.Loader) error {
+	for _, s := range files {
+		c, err := configs.Load(s.Path)
+		if err != nil {
+			return err
+		}
+		if _, err := rules.Enabled(c); err != nil {
+			return fmt.Errorf("%s: %s", s.Path, err)
+		}
+	}
+	return nil
+}
+
+// writeFormatted writes out the formatted contents of the source file in
+// its encoding.
+func writeFormatted(out io.Writer, s csfmt.SourceFile, contents []byte) error {
+	encoded, err := csfmt.Encode(contents, s.Encoding)
+	if err != nil {
+		return fmt.Errorf("%s: %s", s.Path, err)
+	}
+	_, err = out.Write(encoded)
+	return err
+}
+
+// listRules writes a table describing each rule in the library as the
+// configuration has it.
+func listRules(w io.Writer, library []*csfmt.Rule, c *config.Config) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCODE\tENABLED\tOPTIONS\tNAME\tDESCRIPTION")
+	for _, rule := range library {
+		setting := c.Rule(rule.ID)
+		enabled := rule.Enabled
+		if setting.Enabled != nil {
+			enabled = *setting.Enabled
+		}
+		effective, err := rule.Effective(setting.Options)
+		if err != nil {
+			return err
+		}
+		options := []string{}
+		for _, option := range rule.Options {
+			options = append(options, fmt.Sprintf("%s=%v", option.Name, effective[option.Name]))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\t%s\t%s\n", rule.ID, rule.Code, enabled, strings.Join(options, " "), rule.Name, rule.Description)
+	}
+	return tw.Flush()
+}
+
+// formatStandardInput formats, checks, lists or diffs the source read from
+// in, writing what is asked for to out and returning the status to exit
+// with.
+func formatStandardInput(in io.Reader, out io.Writer, configs *config.Loader) (int, error) {
+	raw, err := ioutil.ReadAll(in)
+	if err != nil {
+		return 0, err
+	}
+	source, encoding, err := csfmt.Decode(raw)
+	if err != nil {
+		return 0, err
+	}
+
+	name := *flagAssumeFilename
+	if name == "" {
+		name = "<stdin>"
+	}
+	c, err := configs.Load(name)
+	if err != nil {
+		return 0, err
+	}
+
+	queuedRules, err := rules.Enabled(c)
+	if err != nil {
+		return 0, err
+	}
+
+	var ranges []csfmt.Range
+	if len(*flagLines) > 0 {
+		ranges = csfmt.Ranges(source, *flagLines)
+	}
+	if *flagCheck {
+		diagnostics, err := check(name, source, ranges, queuedRules, c)
+		if err != nil {
+			return 0, err
+		}
+		status := 0
+		for _, diagnostic := range diagnostics {
+			if diagnostic.Severity == csfmt.SeverityError {
+				status = 1
+			}
+			fmt.Fprintln(out, diagnostic)
+		}
+		return status, nil
+	}
+
+	formatted, _, err := format(name, source, ranges, queuedRules)
+	if err != nil {
+		return 0, err
+	}
+	status := 0
+	if *flagList && !bytes.Equal(source, formatted) {
+		fmt.Fprintln(out, name)
+		status = exitDiffers
+	}
+	switch {
+	case *flagDiff:
+		out.Write(diff.Unified(name+".orig", name, source, formatted, 3))
+	case !*flagList:
+		formatted, err = csfmt.Encode(formatted, encoding)
+		if err != nil {
+			return 0, err
+		}
+		out.Write(formatted)
+	}
+	return status, nil
+}
+
+// check finds every violation of the rules within the contents of the file
+// found at path, and within the ranges when there are any, ordered by where
+// they were found.
+func check(path string, contents []byte, ranges []csfmt.Range, queuedRules []*csfmt.Rule, c *config.Config) ([]csfmt.Diagnostic, error) {
+	diagnostics := []csfmt.Diagnostic{}
+	for _, rule := range queuedRules {
+		found, err := rule.Check(path, contents)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		for _, diagnostic := range found {
+			if ranges != nil && !diagnostic.Fix.Within(ranges) {
+				continue
+			}
+			diagnostic.Severity = c.Severity(rule.ID)
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Line != diagnostics[j].Line {
+			return diagnostics[i].Line < diagnostics[j].Line
+		}
+		return diagnostics[i].Column < diagnostics[j].Column
+	})
+	return diagnostics, nil
+}
+
+// ruleOptions collects the options given on the command line by rule.
+type ruleOptions map[string]csfmt.Options
+
+// optionsFlag defines a flag which may be repeated to set options of rules.
+func optionsFlag(name, usage string) ruleOptions {
+	options := ruleOptions{}
+	flag.Var(options, name, usage)
+	return options
+}
+
+func (o ruleOptions) String() string {
+	return ""
+}
+
+func (o ruleOptions) Set(value string) error {
+	setting := strings.SplitN(value, "=", 2)
+	name := strings.SplitN(setting[0], ".", 2)
+	if len(setting) != 2 || len(name) != 2 {
+		return fmt.Errorf("expected RULE.OPTION=VALUE but got %q", value)
+	}
+
+	rule := rules.Lookup(name[0])
+	if rule == nil {
+		return fmt.Errorf("unknown rule %q", name[0])
+	}
+	parsed, err := rule.ParseOptions(csfmt.Options{name[1]: setting[1]})
+	if err != nil {
+		return err
+	}
+
+	if o[rule.ID] == nil {
+		o[rule.ID] = csfmt.Options{}
+	}
+	for option, typed := range parsed {
+		o[rule.ID][option] = typed
+	}
+	return nil
+}
+
+// config turns the options into configuration overriding everything else.
+func (o ruleOptions) config() *config.Config {
+	c := &config.Config{Rules: map[string]config.Rule{}}
+	for id, options := range o {
+		c.Rules[id] = config.Rule{Options: options}
+	}
+	return c
+}
+
+// result is the outcome of processing a single source file.
+type result struct {
+	file        csfmt.SourceFile
+	original    []byte
+	formatted   []byte
+	diagnostics []csfmt.Diagnostic
+	applied     []string
+	reencoded   bool
+	skipped     string
+	err         error
+}
+
+// process reads the source file and either checks or formats it using the
+// rules configured for it.
+func process(s csfmt.SourceFile, configs *config.Loader) result {
+	r := result{file: s}
+	if !*flagIncludeGenerated && s.HasGeneratedName() {
+		r.skipped = "by file name"
+		return r
+	}
+	contents, err := s.Read()
+	if err != nil {
+		r.err = err
+		return r
+	}
+	r.original = contents
+	if !*flagIncludeGenerated && csfmt.HasGeneratedHeader(contents) {
+		r.skipped = "by header"
+		return r
+	}
+	var ranges []csfmt.Range
+	switch {
+	case len(*flagLines) > 0:
+		ranges = csfmt.Ranges(contents, *flagLines)
+	case *flagDiffOnly:
+		lines, err := git.Lines(s.Path, *flagStaged, *flagSince)
+		if err != nil {
+			r.err = err
+			return r
+		}
+		ranges = csfmt.Ranges(contents, lines)
+	}
+	if ranges != nil && len(ranges) == 0 {
+		r.formatted = contents
+		return r
+	}
+
+	c, err := configs.Load(s.Path)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	queuedRules, err := rules.Enabled(c)
+	if err != nil {
+		r.err = fmt.Errorf("%s: %s", s.Path, err)
+		return r
+	}
+	for _, rule := range queuedRules {
+		r.applied = append(r.applied, rule.ID)
+	}
+
+	if *flagCheck {
+		r.diagnostics, r.err = check(s.Path, contents, ranges, queuedRules, c)
+		return r
+	}
+
+	r.formatted, ranges, err = format(s.Path, contents, ranges, queuedRules)
+	if err != nil {
+		r.err = fmt.Errorf("%s: %s", s.Path, err)
+		return r
+	}
+	if *flagVerifyIdempotent {
+		again, _, err := format(s.Path, r.formatted, ranges, queuedRules)
+		if err == nil && !bytes.Equal(r.formatted, again) {
+			err = fmt.Errorf("formatting the result again changed it")
+		}
+		if err != nil {
+			r.err = fmt.Errorf("%s: %s", s.Path, err)
+			return r
+		}
+	}
+	if c.Charset != "" && c.Charset != s.Encoding && ranges == nil {
+		s.Encoding = c.Charset
+		r.reencoded = true
+	}
+	r.file = s
+	if *flagWrite && (r.reencoded || !bytes.Equal(r.original, r.formatted)) {
+		if err := s.Write(r.formatted); err != nil {
+			r.err = err
+		}
+	}
+	return r
+}
+
+// processInOrder hands each file to one of the workers as it arrives and
+// returns their results in the order the files arrived.
+func processInOrder(files <-chan csfmt.SourceFile, workers int, work func(s csfmt.SourceFile) result) <-chan result {
+	if workers < 1 {
+		workers = 1
+	}
+
+	type job struct {
+		index int
+		file  csfmt.SourceFile
+	}
+	type done struct {
+		index  int
+		result result
+	}
+	jobs := make(chan job)
+	finished := make(chan done)
+	window := make(chan struct{}, workers*4)
+
+	// Number each file as it arrives
+	go func() {
+		defer close(jobs)
+		index := 0
+		for file := range files {
+			window <- struct{}{}
+			jobs <- job{index: index, file: file}
+			index++
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				finished <- done{index: j.index, result: work(j.file)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	// Hold on to results which finish early until those before them are done
+	results := make(chan result)
+	go func() {
+		defer close(results)
+		pending := map[int]result{}
+		next := 0
+		for d := range finished {
+			pending[d.index] = d.result
+			for {
+				r, ok := pending[next]
+				if !ok {
+					break
 				}
+				delete(pending, next)
+				results <- r
+				<-window
+				next++
 			}
 		}
+	}()
+	return results
+}
+
+// lineRanges collects the ranges of lines given on the command line.
+type lineRanges []csfmt.LineRange
+
+// linesFlag defines a flag which may be repeated to give ranges of lines.
+func linesFlag(name, usage string) *lineRanges {
+	lines := &lineRanges{}
+	flag.Var(lines, name, usage)
+	return lines
+}
+
+func (l *lineRanges) String() string {
+	parts := []string{}
+	for _, lines := range *l {
+		parts = append(parts, lines.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set adds the range of lines written as start:end.
+func (l *lineRanges) Set(value string) error {
+	lines, err := csfmt.ParseLineRange(value)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, lines)
+	return nil
+}
+
+// ignoredBy returns whether a path is left out by the ignore files found
+// from the top of the repository down to the directory.
+func ignoredBy(dir string) (func(path string, isDir bool) bool, error) {
+	names := []string{ignore.FileName}
+	if *flagGitignore {
+		names = []string{ignore.GitFileName, ignore.FileName}
+	}
+	ignores, err := ignore.NewLoader(dir, names...)
+	if err != nil {
+		return nil, err
 	}
-	count := len(sourceFiles)
-	modified := 0
-	queuedRules := rules.Enabled()
-	for _, s := range sourceFiles {
-		contents, err := s.Read()
+	return func(path string, isDir bool) bool {
+		skip, err := ignores.Ignored(path, isDir)
 		if err != nil {
-			log.Fatalln(err)
+			log.Println(err)
 		}
-		original := contents
+		return skip
+	}, nil
+}
 
-		for _, rule := range queuedRules {
-			contents = rule.Apply(contents)
+// installHook writes the pre-commit hook of the repository containing the
+// directory, returning where it was written.
+func installHook(dir string) (string, error) {
+	hooks, err := git.HooksDir(dir)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(hooks, "pre-commit")
+	if existing, err := ioutil.ReadFile(path); err == nil && !bytes.Contains(existing, []byte(hookMarker)) {
+		return "", fmt.Errorf("%s already exists, add `csfmt hook run` to it instead", path)
+	}
+
+	command := []string{"csfmt"}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "check", "gitignore", "include-generated":
+			command = append(command, "-"+f.Name+"="+f.Value.String())
 		}
+	})
+	command = append(command, "hook", "run")
+	script := fmt.Sprintf("#!/bin/sh\n%s\nexec %s\n", hookMarker, strings.Join(command, " "))
 
-		if bytes.Compare(original, contents) != 0 {
-			modified++
-			if *flagWrite {
-				s.Write(contents)
+	if err := os.MkdirAll(hooks, 0755); err != nil {
+		return "", err
+	}
+	return path, ioutil.WriteFile(path, []byte(script), 0755)
+}
+
+// runHook formats the files staged for the next commit, returning the
+// status to exit with.
+func runHook(configs *config.Loader) int {
+	staged, err := git.Staged(".")
+	if err != nil {
+		log.Println(err)
+		return exitFailed
+	}
+	unstaged, err := git.Unstaged(".")
+	if err != nil {
+		log.Println(err)
+		return exitFailed
+	}
+	partial := map[string]bool{}
+	for _, path := range unstaged {
+		partial[path] = true
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		log.Println(err)
+		return exitFailed
+	}
+	ignored, err := ignoredBy(cwd)
+	if err != nil {
+		log.Println(err)
+		return exitFailed
+	}
+
+	count, modified, failed := 0, 0, 0
+	for _, path := range staged {
+		s := csfmt.SourceFile{Path: path}
+		if !s.IsDotNet() || ignored(path, false) || (!*flagIncludeGenerated && s.HasGeneratedName()) {
+			continue
+		}
+		if info, err := os.Lstat(path); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		formatted, differs, err := formatStaged(path, configs)
+		if err != nil {
+			log.Printf("%s: %s\n", path, err)
+			failed++
+			continue
+		}
+		count++
+		if !differs {
+			continue
+		}
+		if partial[path] && !*flagCheck {
+			log.Printf("%s: formatting differs but the file is only partly staged, stage or stash the rest first\n", path)
+			failed++
+			continue
+		}
+		modified++
+		if *flagCheck {
+			if rel, err := filepath.Rel(cwd, path); err == nil {
+				path = rel
 			}
+			fmt.Println(path)
+			continue
+		}
+
+		if err := git.WriteIndex(path, formatted); err != nil {
+			log.Println(err)
+			failed++
+			continue
 		}
+		if err := formatWorkingTree(s, configs); err != nil {
+			log.Println(err)
+			failed++
+		}
+	}
+
+	if *flagCheck {
+		log.Printf("Formatting differs in %d of %d staged files\n", modified, count)
+	} else {
+		log.Printf("Modified %d of %d staged files\n", modified, count)
+	}
+	switch {
+	case failed > 0:
+		return exitFailed
+	case *flagCheck && modified > 0:
+		return exitDiffers
+	}
+	return 0
+}
 
-		if !*flagWrite {
-			fmt.Println(string(contents))
+// formatStaged formats the contents staged for the file found at path,
+// returning them encoded as they were staged and whether they differ.
+func formatStaged(path string, configs *config.Loader) ([]byte, bool, error) {
+	raw, err := git.ReadIndex(path)
+	if err != nil {
+		return nil, false, err
+	}
+	contents, encoding, err := csfmt.Decode(raw)
+	if err != nil {
+		return nil, false, err
+	}
+	if !*flagIncludeGenerated && csfmt.HasGeneratedHeader(contents) {
+		return raw, false, nil
+	}
+	formatted, err := formatFile(path, contents, configs)
+	if err != nil {
+		return nil, false, err
+	}
+	encoded, err := csfmt.Encode(formatted, encoding)
+	if err != nil {
+		return nil, false, err
+	}
+	return encoded, !bytes.Equal(raw, encoded), nil
+}
+
+// formatWorkingTree formats the source file in place.
+func formatWorkingTree(s csfmt.SourceFile, configs *config.Loader) error {
+	contents, err := s.Read()
+	if err != nil {
+		return err
+	}
+	formatted, err := formatFile(s.Path, contents, configs)
+	if err != nil {
+		return fmt.Errorf("%s: %s", s.Path, err)
+	}
+	if bytes.Equal(contents, formatted) {
+		return nil
+	}
+	return s.Write(formatted)
+}
+
+// formatFile formats the contents of the file found at path using the rules
+// configured for it.
+func formatFile(path string, contents []byte, configs *config.Loader) ([]byte, error) {
+	c, err := configs.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	queuedRules, err := rules.Enabled(c)
+	if err != nil {
+		return nil, err
+	}
+	formatted, _, err := format(path, contents, nil, queuedRules)
+	return formatted, err
+}
+
+// languageServer formats and checks the documents an editor has open the
+// same way files are.
+func languageServer() *lsp.Server {
+	load := func(path string) (*config.Config, []*csfmt.Rule, error) {
+		configs := config.NewLoader(rules.Lookup)
+		configs.EditorConfig = rules.EditorConfig
+		configs.Overrides = flagOptions.config()
+		c, err := configs.Load(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		queuedRules, err := rules.Enabled(c)
+		if err != nil {
+			return nil, nil, err
 		}
+		return c, queuedRules, nil
+	}
+	generated := func(path string, source []byte) bool {
+		return !*flagIncludeGenerated && ((&csfmt.SourceFile{Path: path}).HasGeneratedName() || csfmt.HasGeneratedHeader(source))
+	}
+
+	return &lsp.Server{
+		Format: func(path string, source []byte, ranges []csfmt.Range) ([]byte, error) {
+			if generated(path, source) {
+				return source, nil
+			}
+			_, queuedRules, err := load(path)
+			if err != nil {
+				return nil, err
+			}
+			formatted, _, err := format(path, source, ranges, queuedRules)
+			return formatted, err
+		},
+		Check: func(path string, source []byte) ([]csfmt.Diagnostic, error) {
+			if generated(path, source) {
+				return []csfmt.Diagnostic{}, nil
+			}
+			c, queuedRules, err := load(path)
+			if err != nil {
+				return nil, err
+			}
+			return check(path, source, nil, queuedRules, c)
+		},
+	}
+}
+
+// normalizedLineEndings returns the name of the line ending the formatted
+// contents use throughout where the original mixed them, or nothing.
+func normalizedLineEndings(original, formatted []byte) string {
+	if _, mixed := csfmt.DetectLineEnding(original); !mixed {
+		return ""
+	}
+	ending, mixed := csfmt.DetectLineEnding(formatted)
+	switch {
+	case mixed:
+		return ""
+	case ending == csfmt.CRLF:
+		return "CRLF"
 	}
-	log.Printf("Modified %d of %d files using %d rules\n", modified, count, len(queuedRules))
+	return "LF"
 }
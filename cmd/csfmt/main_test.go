@@ -0,0 +1,528 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/config"
+	"github.com/revolvingcow/csfmt/git"
+	"github.com/revolvingcow/csfmt/rules"
+)
+
+func TestFormat(t *testing.T) {
+	replace := func(id, old, new string) *csfmt.Rule {
+		return &csfmt.Rule{
+			ID: id,
+			Apply: func(source []byte) []byte {
+				return bytes.Replace(source, []byte(old), []byte(new), -1)
+			},
+		}
+	}
+
+	tests := []struct {
+		description string
+		rules       []*csfmt.Rule
+		given       string
+		expected    string
+		fighting    string
+		refused     string
+	}{
+		{
+			description: "later rule creates work for an earlier one",
+			rules:       []*csfmt.Rule{replace("Spaces", "  ", " "), replace("Tabs", "\t", "    ")},
+			given:       "a\tb",
+			expected:    "a b",
+		},
+		{
+			description: "rules undoing each other",
+			rules:       []*csfmt.Rule{replace("Up", " ", "\t"), replace("Down", "\t", " "), replace("Other", "  ", " ")},
+			given:       "a b",
+			fighting:    "Up, Down",
+		},
+		{
+			description: "rule never finishing",
+			rules:       []*csfmt.Rule{replace("Grow", " ", "  ")},
+			given:       "a b",
+			fighting:    "Grow",
+		},
+		{
+			description: "rule changing the code",
+			rules:       []*csfmt.Rule{replace("Spaces", "  ", " "), replace("Split", "++", "+ +")},
+			given:       "a  =  1;\n  i++;",
+			refused:     `Split would change the code: "++" at 2:4 became "+"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual, _, err := format("", []byte(test.given), nil, test.rules)
+			if test.fighting != "" {
+				if err == nil || !strings.HasSuffix(err.Error(), ": "+test.fighting) {
+					t.Errorf("Got `%v` but wanted %s named", err, test.fighting)
+				}
+				return
+			}
+			if test.refused != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), test.refused) {
+					t.Errorf("Got `%v` but wanted `%s`", err, test.refused)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(actual) != test.expected {
+				t.Errorf("Got `%s` but wanted `%s`", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestListRules(t *testing.T) {
+	off := false
+	c := &config.Config{Rules: map[string]config.Rule{
+		"TabsMustNotBeUsed": {Enabled: &off, Options: csfmt.Options{"tab-width": 2.0}},
+	}}
+	out := &bytes.Buffer{}
+	if err := listRules(out, rules.Library, c); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(out.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != "TabsMustNotBeUsed" {
+			continue
+		}
+		if actual := strings.Join(fields[1:4], " "); actual != "SA1027 false tab-width=2" {
+			t.Errorf("Got `%s` but wanted `SA1027 false tab-width=2`", actual)
+		}
+		return
+	}
+	t.Errorf("Got `%s` but wanted the tabs rule listed", out.String())
+}
+
+func TestProcessInOrder(t *testing.T) {
+	tests := []struct {
+		description string
+		workers     int
+		files       int
+		group       int
+	}{
+		{description: "last file first", workers: 8, files: 8, group: 8},
+		{description: "more files than workers", workers: 2, files: 20, group: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			finished := make([]chan struct{}, test.files)
+			for i := range finished {
+				finished[i] = make(chan struct{})
+			}
+			var mu sync.Mutex
+			completed := []int{}
+
+			files := make(chan csfmt.SourceFile)
+			go func() {
+				defer close(files)
+				for i := 0; i < test.files; i++ {
+					files <- csfmt.SourceFile{Path: fmt.Sprintf("%d.cs", i)}
+				}
+			}()
+			results := processInOrder(files, test.workers, func(s csfmt.SourceFile) result {
+				var i int
+				fmt.Sscanf(s.Path, "%d.cs", &i)
+				if (i+1)%test.group != 0 && i+1 < test.files {
+					<-finished[i+1]
+				}
+
+				mu.Lock()
+				completed = append(completed, i)
+				mu.Unlock()
+				close(finished[i])
+
+				r := result{file: s}
+				if i%2 == 0 {
+					r.formatted = []byte(s.Path)
+				}
+				return r
+			})
+
+			count, modified := 0, 0
+			for r := range results {
+				if expected := fmt.Sprintf("%d.cs", count); r.file.Path != expected {
+					t.Errorf("Got `%s` but wanted `%s`", r.file.Path, expected)
+				}
+				if r.formatted != nil {
+					modified++
+				}
+				count++
+			}
+			if count != test.files || modified != test.files/2 {
+				t.Errorf("Got %d files with %d modified but wanted %d with %d", count, modified, test.files, test.files/2)
+			}
+			if completed[0] != test.group-1 {
+				t.Errorf("Got %v finishing but wanted file %d first", completed, test.group-1)
+			}
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csfmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		filepath.Join("a", "x.cs"):          "",
+		filepath.Join("b", "y.cs"):          "",
+		filepath.Join("b", config.FileName): `{"rules": {"TabsMustNotBeUsed": {"options": {"tab-width": 0}}}}`,
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	good := csfmt.SourceFile{Path: filepath.Join(dir, "a", "x.cs")}
+	bad := csfmt.SourceFile{Path: filepath.Join(dir, "b", "y.cs")}
+	if err := configure([]csfmt.SourceFile{good}, config.NewLoader(rules.Lookup)); err != nil {
+		t.Errorf("Got `%s` but wanted no error", err)
+	}
+	err = configure([]csfmt.SourceFile{good, bad}, config.NewLoader(rules.Lookup))
+	if err == nil || !strings.Contains(err.Error(), "tab-width") {
+		t.Errorf("Got `%v` but wanted the bad option reported", err)
+	}
+}
+
+func TestFormatStandardInput(t *testing.T) {
+	tests := []struct {
+		description string
+		flag        *bool
+		expected    string
+		status      int
+	}{
+		{
+			description: "format",
+			expected:    "F(a, b);\n",
+		},
+		{
+			description: "check",
+			flag:        flagCheck,
+			expected:    "<stdin>:1:4: error: remove \" \" [SA1001 CommasMustBeSpacedCorrectly]\n<stdin>:1:6: error: insert \" \" [SA1001 CommasMustBeSpacedCorrectly]\n",
+			status:      1,
+		},
+		{
+			description: "list",
+			flag:        flagList,
+			expected:    "<stdin>\n",
+			status:      exitDiffers,
+		},
+		{
+			description: "diff",
+			flag:        flagDiff,
+			expected:    "--- <stdin>.orig\n+++ <stdin>\n@@ -1 +1 @@\n-F(a ,b);\n+F(a, b);\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if test.flag != nil {
+				*test.flag = true
+				defer func() { *test.flag = false }()
+			}
+			out := &bytes.Buffer{}
+			status, err := formatStandardInput(strings.NewReader("F(a ,b);\n"), out, config.NewLoader(rules.Lookup))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != test.expected {
+				t.Errorf("Got `%s` but wanted `%s`", out.String(), test.expected)
+			}
+			if status != test.status {
+				t.Errorf("Got status %d but wanted %d", status, test.status)
+			}
+		})
+	}
+}
+
+func TestInstallHook(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "csfmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %s", out)
+	}
+
+	path, err := installHook(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(dir, ".git", "hooks", "pre-commit"); path != expected {
+		t.Errorf("Got `%s` but wanted `%s`", path, expected)
+	}
+	script, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(script), "exec csfmt hook run\n") {
+		t.Errorf("Got `%s` but wanted it to run the hook", script)
+	}
+
+	if _, err := installHook(dir); err != nil {
+		t.Errorf("Got `%s` but wanted the hook replaced", err)
+	}
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\nmake lint\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := installHook(dir); err == nil {
+		t.Errorf("Got no error but wanted another hook left alone")
+	}
+}
+
+// stagedRepository returns a new repository with a file staged and then
+// changed in the working tree.
+func stagedRepository(t *testing.T, name, staged, working string) string {
+	dir, err := ioutil.TempDir("", "csfmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %s", out)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(staged), 0644); err != nil {
+		t.Fatal(err)
+	}
+	add := exec.Command("git", "add", name)
+	add.Dir = dir
+	if out, err := add.CombinedOutput(); err != nil {
+		t.Fatalf("git add: %s", out)
+	}
+	if err := ioutil.WriteFile(path, []byte(working), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestFormatStaged(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	tests := []struct {
+		description string
+		staged      string
+		expected    string
+		differs     bool
+	}{
+		{description: "formatted", staged: "F(a, b);\n", expected: "F(a, b);\n"},
+		{description: "unformatted", staged: "F(a ,b);\n", expected: "F(a, b);\n", differs: true},
+		{description: "byte order mark kept", staged: "\xef\xbb\xbfF(a ,b);\n", expected: "\xef\xbb\xbfF(a, b);\n", differs: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			dir := stagedRepository(t, "Program.cs", test.staged, "G(c ,d);\n")
+			defer os.RemoveAll(dir)
+
+			formatted, differs, err := formatStaged(filepath.Join(dir, "Program.cs"), config.NewLoader(rules.Lookup))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(formatted) != test.expected {
+				t.Errorf("Got `%s` but wanted `%s`", formatted, test.expected)
+			}
+			if differs != test.differs {
+				t.Errorf("Got differs %t but wanted %t", differs, test.differs)
+			}
+		})
+	}
+}
+
+func TestRunHook(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+
+	tests := []struct {
+		description string
+		flag        *bool
+		staged      string
+		working     string
+		index       string
+		tree        string
+		status      int
+	}{
+		{
+			description: "staged file",
+			staged:      "F(a ,b);\n",
+			working:     "F(a ,b);\n",
+			index:       "F(a, b);\n",
+			tree:        "F(a, b);\n",
+		},
+		{
+			description: "staged file checked",
+			flag:        flagCheck,
+			staged:      "F(a ,b);\n",
+			working:     "F(a ,b);\n",
+			index:       "F(a ,b);\n",
+			tree:        "F(a ,b);\n",
+			status:      exitDiffers,
+		},
+		{
+			description: "partly staged file",
+			staged:      "F(a ,b);\n",
+			working:     "F(a ,b);\nG(c ,d);\n",
+			index:       "F(a ,b);\n",
+			tree:        "F(a ,b);\nG(c ,d);\n",
+			status:      exitFailed,
+		},
+		{
+			description: "partly staged file already formatted",
+			staged:      "F(a, b);\n",
+			working:     "F(a, b);\nG(c ,d);\n",
+			index:       "F(a, b);\n",
+			tree:        "F(a, b);\nG(c ,d);\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if test.flag != nil {
+				*test.flag = true
+				defer func() { *test.flag = false }()
+			}
+			dir := stagedRepository(t, "Program.cs", test.staged, test.working)
+			defer os.RemoveAll(dir)
+			if err := os.Chdir(dir); err != nil {
+				t.Fatal(err)
+			}
+			defer os.Chdir(cwd)
+
+			status := runHook(config.NewLoader(rules.Lookup))
+			if status != test.status {
+				t.Errorf("Got status %d but wanted %d", status, test.status)
+			}
+			path := filepath.Join(dir, "Program.cs")
+			index, err := git.ReadIndex(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(index) != test.index {
+				t.Errorf("Got `%s` staged but wanted `%s`", index, test.index)
+			}
+			tree, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(tree) != test.tree {
+				t.Errorf("Got `%s` in the working tree but wanted `%s`", tree, test.tree)
+			}
+		})
+	}
+}
+
+func TestWriteFormatted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csfmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		description string
+		encoding    csfmt.Encoding
+		given       []byte
+		expected    []byte
+	}{
+		{
+			description: "utf-8",
+			encoding:    csfmt.UTF8,
+			given:       []byte("F(a ,b);\n"),
+			expected:    []byte("F(a, b);\n"),
+		},
+		{
+			description: "utf-8 with a byte order mark",
+			encoding:    csfmt.UTF8BOM,
+			given:       []byte("\xef\xbb\xbfF(a ,b);\n"),
+			expected:    []byte("\xef\xbb\xbfF(a, b);\n"),
+		},
+		{
+			description: "utf-16",
+			encoding:    csfmt.UTF16LE,
+			given:       []byte("\xff\xfeF\x00(\x00a\x00 \x00,\x00b\x00)\x00;\x00\n\x00"),
+			expected:    []byte("\xff\xfeF\x00(\x00a\x00,\x00 \x00b\x00)\x00;\x00\n\x00"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			path := filepath.Join(dir, "Program.cs")
+			if err := ioutil.WriteFile(path, test.given, 0644); err != nil {
+				t.Fatal(err)
+			}
+			r := process(csfmt.SourceFile{Path: path}, config.NewLoader(rules.Lookup))
+			if r.err != nil {
+				t.Fatal(r.err)
+			}
+			if r.file.Encoding != test.encoding {
+				t.Errorf("Got `%s` but wanted `%s`", r.file.Encoding, test.encoding)
+			}
+			out := &bytes.Buffer{}
+			if err := writeFormatted(out, r.file, r.formatted); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out.Bytes(), test.expected) {
+				t.Errorf("Got `%q` but wanted `%q`", out.Bytes(), test.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizedLineEndings(t *testing.T) {
+	tests := []struct {
+		description string
+		original    string
+		formatted   string
+		expected    string
+	}{
+		{description: "consistent", original: "a;\r\nb;\r\n", formatted: "a;\nb;\n", expected: ""},
+		{description: "mixed changed to lf", original: "a;\r\nb;\nc;\n", formatted: "a;\nb;\nc;\n", expected: "LF"},
+		{description: "mixed changed to crlf", original: "a;\r\nb;\nc;\r\n", formatted: "a;\r\nb;\r\nc;\r\n", expected: "CRLF"},
+		{description: "mixed left alone", original: "a;\r\nb;\n", formatted: "a;\r\nb;\n", expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual := normalizedLineEndings([]byte(test.original), []byte(test.formatted))
+			if actual != test.expected {
+				t.Errorf("Got `%s` but wanted `%s`", actual, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package csfmt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/revolvingcow/csfmt/lexer"
+)
+
+// codeToken is a token which means something to the compiler.
+type codeToken struct {
+	kind   lexer.Kind
+	text   string
+	offset int
+	line   int
+	column int
+}
+
+// UsingDirective is a using directive of a source along with the scope it
+// belongs to.
+type UsingDirective struct {
+	// Start and End are the offsets of the directive, from the using
+	// keyword up to and including the semicolon.
+	Start int
+	End   int
+
+	// Scope is shared by every directive within the same namespace and
+	// preprocessor region, and by no other.
+	Scope string
+
+	text   string
+	within []scope
+}
+
+// scope is a namespace or preprocessor region opened by the code token
+// found at index.
+type scope struct {
+	index int
+	name  string
+}
+
+// SameCode checks the formatted source is the same code as the original,
+// returning an error describing the first difference if it is not.
+func SameCode(original, formatted []byte) error {
+	return SameCodeFrom(original, original, formatted)
+}
+
+// SameCodeFrom is SameCode reporting positions within the source the
+// original was formatted from, which has to be the same code.
+func SameCodeFrom(source, original, formatted []byte) error {
+	before, beforeUsings := code(original)
+	after, afterUsings := code(formatted)
+
+	// Only look at the source once there is something to report
+	var reference []codeToken
+	at := func(i int) string {
+		if reference == nil {
+			reference, _ = code(source)
+		}
+		if i < len(reference) {
+			return fmt.Sprintf("%d:%d", reference[i].line, reference[i].column)
+		}
+		line, column := Position(source, len(source))
+		return fmt.Sprintf("%d:%d", line, column)
+	}
+	within := func(using UsingDirective) string {
+		scopes := []string{}
+		for _, scope := range using.within {
+			scopes = append(scopes, fmt.Sprintf("%s at %s", scope.name, at(scope.index)))
+		}
+		if len(scopes) == 0 {
+			return ""
+		}
+		return " within " + strings.Join(scopes, " and ")
+	}
+
+	for i := 0; i < len(before) && i < len(after); i++ {
+		if before[i].text != after[i].text {
+			return fmt.Errorf("%q at %s became %q", before[i].text, at(i), after[i].text)
+		}
+	}
+	if len(before) > len(after) {
+		return fmt.Errorf("%q at %s was removed", before[len(after)].text, at(len(after)))
+	}
+	if len(after) > len(before) {
+		return fmt.Errorf("%q was added at %s", after[len(before)].text, at(len(before)))
+	}
+
+	// The rest of the code is the same so scopes are found at the same
+	// tokens in both
+	count := map[string]int{}
+	for _, using := range beforeUsings {
+		count[using.Scope+" "+using.text]++
+	}
+	for _, using := range afterUsings {
+		count[using.Scope+" "+using.text]--
+	}
+	for _, using := range beforeUsings {
+		if count[using.Scope+" "+using.text] > 0 {
+			return fmt.Errorf("%q%s was removed", using.text, within(using))
+		}
+	}
+	for _, using := range afterUsings {
+		if count[using.Scope+" "+using.text] < 0 {
+			return fmt.Errorf("%q was added%s", using.text, within(using))
+		}
+	}
+	return nil
+}
+
+// UsingDirectives returns the using directives of the source in the order
+// they are found.
+func UsingDirectives(source []byte) []UsingDirective {
+	_, usings := code(source)
+	return usings
+}
+
+// code returns the tokens of the source which are not trivia, setting the
+// using directives aside since their order within a scope doesn't matter.
+func code(source []byte) ([]codeToken, []UsingDirective) {
+	tokens := []codeToken{}
+	usings := []UsingDirective{}
+
+	// Whether each open brace belongs to a namespace, along with the
+	// namespaces and preprocessor regions open so far
+	braces := []bool{}
+	namespace := -1
+	namespaces := []scope{}
+	regions := []scope{}
+	directive := -1
+	for _, token := range lexer.Lex(source) {
+		if token.Kind.IsTrivia() {
+			continue
+		}
+
+		text := string(token.Text)
+		if token.Kind == lexer.Preprocessor {
+			text = preprocessorCode(text)
+		}
+		switch {
+		case token.Is(lexer.Keyword, "namespace"):
+			namespace = len(tokens)
+		case token.Is(lexer.Punctuation, "{"):
+			braces = append(braces, namespace >= 0)
+			if namespace >= 0 {
+				namespaces = append(namespaces, namespaceScope(tokens, namespace))
+			}
+			namespace = -1
+		case token.Is(lexer.Punctuation, "}"):
+			if len(braces) > 0 {
+				if braces[len(braces)-1] && len(namespaces) > 0 {
+					namespaces = namespaces[:len(namespaces)-1]
+				}
+				braces = braces[:len(braces)-1]
+			}
+		case token.Is(lexer.Punctuation, ";") && namespace >= 0:
+			namespaces = append(namespaces, namespaceScope(tokens, namespace))
+			namespace = -1
+		case token.Kind == lexer.Preprocessor:
+			regions = preprocessorRegions(regions, text, scope{index: len(tokens), name: text})
+		case token.Is(lexer.Keyword, "using") && directive < 0 && statementStart(tokens) && inNamespaces(braces):
+			directive = len(tokens)
+		}
+		tokens = append(tokens, codeToken{kind: token.Kind, text: text, offset: token.Offset, line: token.Line, column: token.Column})
+
+		if directive >= 0 && token.Is(lexer.Punctuation, ";") {
+			if isUsingDirective(tokens[directive+1 : len(tokens)-1]) {
+				words := []string{}
+				for _, t := range tokens[directive:] {
+					words = append(words, t.text)
+				}
+				using := UsingDirective{
+					Start: tokens[directive].offset,
+					End:   token.End(),
+					text:  strings.Join(words, " "),
+				}
+				opened := []string{}
+				for _, open := range [][]scope{namespaces, regions} {
+					if len(open) == 0 {
+						opened = append(opened, "-")
+						continue
+					}
+					innermost := open[len(open)-1]
+					opened = append(opened, fmt.Sprint(innermost.index))
+					using.within = append(using.within, innermost)
+				}
+				using.Scope = strings.Join(opened, " ")
+				usings = append(usings, using)
+				tokens = tokens[:directive]
+			}
+			directive = -1
+		}
+	}
+	return tokens, usings
+}
+
+// namespaceScope returns the scope of the namespace declared by the tokens
+// from the namespace keyword at start onwards.
+func namespaceScope(tokens []codeToken, start int) scope {
+	name := "namespace "
+	for _, token := range tokens[start+1:] {
+		name += token.text
+	}
+	return scope{index: start, name: name}
+}
+
+// preprocessorRegions returns the regions open after the preprocessor
+// directive, which opens the given region when it starts a branch.
+func preprocessorRegions(regions []scope, directive string, region scope) []scope {
+	keyword := strings.TrimPrefix(strings.SplitN(directive, " ", 2)[0], "#")
+	switch {
+	case keyword == "if":
+		return append(regions, region)
+	case (keyword == "elif" || keyword == "else") && len(regions) > 0:
+		return append(regions[:len(regions)-1], region)
+	case keyword == "endif" && len(regions) > 0:
+		return regions[:len(regions)-1]
+	}
+	return regions
+}
+
+// statementStart reports whether the next token would begin a statement.
+func statementStart(tokens []codeToken) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == lexer.Preprocessor || last.text == ";" || last.text == "{" || last.text == "}"
+}
+
+// inNamespaces reports whether every open brace belongs to a namespace.
+func inNamespaces(braces []bool) bool {
+	for _, namespace := range braces {
+		if !namespace {
+			return false
+		}
+	}
+	return true
+}
+
+// isUsingDirective reports whether the tokens between using and the
+// semicolon name a namespace or type rather than declare a variable.
+func isUsingDirective(tokens []codeToken) bool {
+	if len(tokens) > 0 && tokens[0].text == "static" {
+		tokens = tokens[1:]
+	}
+	for i, token := range tokens {
+		if token.text == "(" {
+			return false
+		}
+		if i > 0 && isWord(token) && isWord(tokens[i-1]) {
+			return false
+		}
+	}
+	return len(tokens) > 0
+}
+
+func isWord(token codeToken) bool {
+	return token.kind == lexer.Identifier || token.kind == lexer.Keyword
+}
+
+// preprocessorCode returns the words of a preprocessor directive.
+func preprocessorCode(text string) string {
+	if comment := strings.Index(text, "//"); comment >= 0 {
+		text = text[:comment]
+	}
+	text = strings.TrimPrefix(text, "#")
+	return "#" + strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,154 @@
+package csfmt
+
+import (
+	"testing"
+)
+
+func TestSameCode(t *testing.T) {
+	tests := []struct {
+		description string
+		original    string
+		formatted   string
+		expected    string
+	}{
+		{
+			description: "whitespace and comments",
+			original:    "class A {\r\n\tint a=1;//one\n}",
+			formatted:   "class A\n{\n    int a = 1; // one\n}\n",
+		},
+		{
+			description: "sorted using directives",
+			original:    "using System.Linq;\nusing static System.Math;\nusing System;\nnamespace A {\nusing F;\nusing B = C.D<E>;\n}",
+			formatted:   "using System;\nusing System.Linq;\nusing static System.Math;\nnamespace A {\nusing B = C.D<E>;\nusing F;\n}",
+		},
+		{
+			description: "sorted within a preprocessor region",
+			original:    "using Z;\n#if DEBUG\nusing Y;\nusing X;\n#else\nusing W;\n#endif\nclass C {}",
+			formatted:   "using Z;\n#if DEBUG\nusing X;\nusing Y;\n#else\nusing W;\n#endif\nclass C {}",
+		},
+		{
+			description: "preprocessor keywords",
+			original:    "# if  DEBUG //on\nx();\n#endif",
+			formatted:   "#if DEBUG // on\nx();\n#endif",
+		},
+		{
+			description: "split operator",
+			original:    "i++;",
+			formatted:   "i+ +;",
+			expected:    `"++" at 1:2 became "+"`,
+		},
+		{
+			description: "inside a string",
+			original:    `s = "a,b";`,
+			formatted:   `s = "a, b";`,
+			expected:    `"\"a,b\"" at 1:5 became "\"a, b\""`,
+		},
+		{
+			description: "removed",
+			original:    "a; b;",
+			formatted:   "a;",
+			expected:    `"b" at 1:4 was removed`,
+		},
+		{
+			description: "using declaration moved out of a method",
+			original:    "class A {\nvoid B() {\nusing var c = D();\n}\n}",
+			formatted:   "using var c = D();\nclass A {\nvoid B() {\n}\n}",
+			expected:    `"class" at 1:1 became "using"`,
+		},
+		{
+			description: "using directive changed",
+			original:    "using System;",
+			formatted:   "using Sys;",
+			expected:    `"using System ;" was removed`,
+		},
+		{
+			description: "using alias hoisted out of a namespace",
+			original:    "namespace A {\nusing B = C.D;\nclass E {}\n}",
+			formatted:   "using B = C.D;\nnamespace A {\nclass E {}\n}",
+			expected:    `"using B = C . D ;" within namespace A at 1:1 was removed`,
+		},
+		{
+			description: "using directive hoisted out of a preprocessor region",
+			original:    "using Z;\n#if DEBUG\nusing X;\n#endif\nclass C {}",
+			formatted:   "using X;\nusing Z;\n#if DEBUG\n#endif\nclass C {}",
+			expected:    `"using X ;" within #if DEBUG at 2:1 was removed`,
+		},
+		{
+			description: "using directive moved to another branch",
+			original:    "#if DEBUG\nusing X;\n#else\nusing Y;\n#endif",
+			formatted:   "#if DEBUG\nusing Y;\n#else\nusing X;\n#endif",
+			expected:    `"using X ;" within #if DEBUG at 1:1 was removed`,
+		},
+		{
+			description: "using directive moved into a file scoped namespace",
+			original:    "using X;\nnamespace A;\nclass C {}",
+			formatted:   "namespace A;\nusing X;\nclass C {}",
+			expected:    `"using X ;" was removed`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			err := SameCode([]byte(test.original), []byte(test.formatted))
+			actual := ""
+			if err != nil {
+				actual = err.Error()
+			}
+			if actual != test.expected {
+				t.Errorf("Got `%s` but wanted `%s`", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestSameCodeFrom(t *testing.T) {
+	tests := []struct {
+		description string
+		source      string
+		original    string
+		formatted   string
+		expected    string
+	}{
+		{
+			description: "same code",
+			source:      "a  =  1;",
+			original:    "a = 1;",
+			formatted:   "a = 1 ;",
+			expected:    "",
+		},
+		{
+			description: "token changed",
+			source:      "a  =  1;\n  i++;",
+			original:    "a = 1;\n i++;",
+			formatted:   "a = 1;\n i+ +;",
+			expected:    `"++" at 2:4 became "+"`,
+		},
+		{
+			description: "token added",
+			source:      "a  =  1;\n",
+			original:    "a = 1;\n",
+			formatted:   "a = 1;\nb;\n",
+			expected:    `"b" was added at 2:1`,
+		},
+		{
+			description: "using directive hoisted out of a namespace",
+			source:      "\n\nnamespace A\n{\n    using B;\n}",
+			original:    "namespace A\n{\n    using B;\n}",
+			formatted:   "using B;\nnamespace A\n{\n}",
+			expected:    `"using B ;" within namespace A at 3:1 was removed`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			err := SameCodeFrom([]byte(test.source), []byte(test.original), []byte(test.formatted))
+			actual := ""
+			if err != nil {
+				actual = err.Error()
+			}
+			if actual != test.expected {
+				t.Errorf("Got `%s` but wanted `%s`", actual, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+// Package config finds and merges the project configuration files which
+// decide how each source file is formatted.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/editorconfig"
+)
+
+// FileName is the name of the configuration file looked for in the
+// directory of each source file and every directory above it.
+const FileName = ".csfmt.json"
+
+// Config is the configuration in effect for a source file.
+type Config struct {
+	// Root stops the search for configuration files in parent directories.
+	Root  bool            `json:"root"`
+	Rules map[string]Rule `json:"rules"`
+
+	// Charset is the encoding files are written in. Files keep the encoding
+	// they were read in when it is left out.
+	Charset csfmt.Encoding `json:"charset"`
+}
+
+// Rule is the configuration of a single rule. Anything left out is
+// inherited from the parent configuration or the rule's defaults.
+type Rule struct {
+	Enabled  *bool          `json:"enabled"`
+	Severity csfmt.Severity `json:"severity"`
+	Options  csfmt.Options  `json:"options"`
+}
+
+// Rule returns the configuration of the rule with the given identifier.
+func (c *Config) Rule(id string) Rule {
+	if c == nil {
+		return Rule{}
+	}
+	return c.Rules[id]
+}
+
+// Severity returns how seriously violations of the rule with the given
+// identifier should be taken.
+func (c *Config) Severity(id string) csfmt.Severity {
+	if severity := c.Rule(id).Severity; severity != "" {
+		return severity
+	}
+	return csfmt.SeverityError
+}
+
+// Merge returns a new configuration where the settings of the child
+// override those of the parent.
+func (c *Config) Merge(child *Config) *Config {
+	merged := &Config{
+		Root:    child.Root,
+		Rules:   map[string]Rule{},
+		Charset: c.Charset,
+	}
+	if child.Charset != "" {
+		merged.Charset = child.Charset
+	}
+	for id, rule := range c.Rules {
+		merged.Rules[id] = rule
+	}
+	for id, rule := range child.Rules {
+		parent := merged.Rules[id]
+		if rule.Enabled != nil {
+			parent.Enabled = rule.Enabled
+		}
+		if rule.Severity != "" {
+			parent.Severity = rule.Severity
+		}
+		if len(rule.Options) > 0 {
+			options := csfmt.Options{}
+			for name, value := range parent.Options {
+				options[name] = value
+			}
+			for name, value := range rule.Options {
+				options[name] = value
+			}
+			parent.Options = options
+		}
+		merged.Rules[id] = parent
+	}
+	return merged
+}
+
+// Read parses a single configuration file. Rules may be referred to by any
+// name the lookup function understands and are stored by their identifier
+// with options of the types the rule declares.
+func Read(path string, lookup func(name string) *csfmt.Rule) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := &Config{}
+	if err := json.Unmarshal(data, raw); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	c := &Config{
+		Root:  raw.Root,
+		Rules: map[string]Rule{},
+	}
+	if raw.Charset != "" {
+		c.Charset, err = csfmt.ParseEncoding(string(raw.Charset))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+	for name, rule := range raw.Rules {
+		found := lookup(name)
+		if found == nil {
+			return nil, fmt.Errorf("%s: unknown rule %q", path, name)
+		}
+		id := found.ID
+		switch rule.Severity {
+		case "", csfmt.SeverityError, csfmt.SeverityWarning, csfmt.SeverityInfo:
+		default:
+			return nil, fmt.Errorf("%s: rule %q has unknown severity %q", path, name, rule.Severity)
+		}
+		if _, ok := c.Rules[id]; ok {
+			return nil, fmt.Errorf("%s: rule %q is configured more than once", path, name)
+		}
+		if len(rule.Options) > 0 {
+			rule.Options, err = found.ParseOptions(rule.Options)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", path, err)
+			}
+		}
+		c.Rules[id] = rule
+	}
+	return c, nil
+}
+
+// Loader finds the configuration in effect for source files, remembering
+// what it has already read for each directory. The workers formatting files
+// in parallel share a single loader, so each directory is only read once.
+type Loader struct {
+	// EditorConfig translates the .editorconfig properties in effect for a
+	// file into configuration. Those files are ignored when it is unset.
+	EditorConfig func(properties map[string]string) *Config
+
+	// Overrides is applied above every configuration file, such as the
+	// options given on the command line.
+	Overrides *Config
+
+	lookup       func(name string) *csfmt.Rule
+	mu           sync.Mutex
+	cache        map[string]*Config
+	editorconfig *editorconfig.Loader
+}
+
+// NewLoader creates a loader which finds rules by name using the given
+// function.
+func NewLoader(lookup func(name string) *csfmt.Rule) *Loader {
+	return &Loader{
+		lookup:       lookup,
+		cache:        map[string]*Config{},
+		editorconfig: editorconfig.NewLoader(),
+	}
+}
+
+// Load returns the configuration in effect for the source file found at
+// path. Configuration files are looked for from the file's directory up to
+// the root of the file system, with files closer to the source overriding
+// those further away. Anything set by .editorconfig files applies beneath
+// every configuration file.
+func (l *Loader) Load(path string) (*Config, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	c, err := l.directory(filepath.Dir(abs))
+	l.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if l.EditorConfig != nil {
+		properties, err := l.editorconfig.Properties(abs)
+		if err != nil {
+			return nil, err
+		}
+		if len(properties) > 0 {
+			c = l.EditorConfig(properties).Merge(c)
+		}
+	}
+	if l.Overrides != nil {
+		c = c.Merge(l.Overrides)
+	}
+	return c, nil
+}
+
+func (l *Loader) directory(dir string) (*Config, error) {
+	if c, ok := l.cache[dir]; ok {
+		return c, nil
+	}
+
+	var c *Config
+	file := filepath.Join(dir, FileName)
+	if _, err := os.Stat(file); err == nil {
+		c, err = Read(file, l.lookup)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	parent := filepath.Dir(dir)
+	switch {
+	case c != nil && c.Root:
+	case parent == dir:
+		if c == nil {
+			c = &Config{Rules: map[string]Rule{}}
+		}
+	default:
+		inherited, err := l.directory(parent)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			c = inherited
+		} else {
+			c = inherited.Merge(c)
+		}
+	}
+
+	l.cache[dir] = c
+	return c, nil
+}
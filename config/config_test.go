@@ -0,0 +1,190 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/revolvingcow/csfmt"
+)
+
+var (
+	tabs = &csfmt.Rule{
+		ID:   "Tabs",
+		Code: "SA1027",
+		Options: []csfmt.Option{
+			{Name: "tab-width", Type: csfmt.OptionInt, Default: 4, Validate: csfmt.AtLeast(1)},
+			{Name: "keep", Type: csfmt.OptionBool, Default: false},
+		},
+	}
+	symbols = &csfmt.Rule{ID: "Symbols", Code: "SA1003"}
+)
+
+func lookup(name string) *csfmt.Rule {
+	for _, rule := range []*csfmt.Rule{tabs, symbols} {
+		if strings.EqualFold(rule.ID, name) || strings.EqualFold(rule.Code, name) {
+			return rule
+		}
+	}
+	return nil
+}
+
+func write(t *testing.T, path, contents string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csfmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write(t, filepath.Join(dir, FileName), `{
+		"root": true,
+		"charset": "UTF-8-BOM",
+		"rules": {
+			"SA1027": {"enabled": false, "options": {"tab-width": 2, "keep": true}},
+			"Symbols": {"severity": "warning"}
+		}
+	}`)
+	write(t, filepath.Join(dir, "nested", FileName), `{
+		"rules": {
+			"tabs": {"enabled": true, "options": {"tab-width": 8}}
+		}
+	}`)
+
+	on, off := true, false
+	tests := []struct {
+		description string
+		given       string
+		expected    map[string]Rule
+		charset     csfmt.Encoding
+	}{
+		{
+			description: "top level configuration",
+			given:       filepath.Join(dir, "A.cs"),
+			expected: map[string]Rule{
+				"Tabs":    {Enabled: &off, Options: csfmt.Options{"tab-width": 2, "keep": true}},
+				"Symbols": {Severity: csfmt.SeverityWarning},
+			},
+			charset: csfmt.UTF8BOM,
+		},
+		{
+			description: "nested configuration overrides its parent",
+			given:       filepath.Join(dir, "nested", "deeper", "B.cs"),
+			expected: map[string]Rule{
+				"Tabs":    {Enabled: &on, Options: csfmt.Options{"tab-width": 8, "keep": true}},
+				"Symbols": {Severity: csfmt.SeverityWarning},
+			},
+			charset: csfmt.UTF8BOM,
+		},
+	}
+
+	loader := NewLoader(lookup)
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual, err := loader.Load(test.given)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(test.expected, actual.Rules) {
+				t.Errorf("Got `%v` but wanted `%v`", actual.Rules, test.expected)
+			}
+			if actual.Charset != test.charset {
+				t.Errorf("Got `%s` but wanted `%s`", actual.Charset, test.charset)
+			}
+		})
+	}
+}
+
+func TestLoadEditorConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csfmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write(t, filepath.Join(dir, ".editorconfig"), "root = true\n[*.cs]\nindent_style = tab\ntab_width = 2\n")
+	write(t, filepath.Join(dir, FileName), `{"root": true, "rules": {"tabs": {"options": {"tab-width": 8}}}}`)
+
+	loader := NewLoader(lookup)
+	loader.EditorConfig = func(properties map[string]string) *Config {
+		enabled := properties["indent_style"] != "tab"
+		return &Config{Rules: map[string]Rule{
+			"Tabs": {Enabled: &enabled, Options: csfmt.Options{"tab-width": properties["tab_width"], "from": "editorconfig"}},
+		}}
+	}
+
+	off := false
+	expected := map[string]Rule{
+		"Tabs": {Enabled: &off, Options: csfmt.Options{"tab-width": 8, "from": "editorconfig"}},
+	}
+	actual, err := loader.Load(filepath.Join(dir, "A.cs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, actual.Rules) {
+		t.Errorf("Got `%v` but wanted `%v`", actual.Rules, expected)
+	}
+
+	loader = NewLoader(lookup)
+	loader.Overrides = &Config{Rules: map[string]Rule{
+		"Tabs": {Options: csfmt.Options{"tab-width": 3}},
+	}}
+	actual, err = loader.Load(filepath.Join(dir, "A.cs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if width := actual.Rule("Tabs").Options["tab-width"]; width != 3 {
+		t.Errorf("Got `%v` but wanted the override", width)
+	}
+
+	actual, err = loader.Load(filepath.Join(dir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := actual.Rules["Tabs"]; !ok || actual.Rules["Tabs"].Enabled != nil {
+		t.Errorf("Got `%v` but wanted only the configuration file", actual.Rules)
+	}
+}
+
+func TestRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csfmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		description string
+		given       string
+	}{
+		{description: "unknown rule", given: `{"rules": {"Nope": {}}}`},
+		{description: "unknown severity", given: `{"rules": {"Tabs": {"severity": "fatal"}}}`},
+		{description: "rule configured twice", given: `{"rules": {"Tabs": {}, "SA1027": {}}}`},
+		{description: "unknown option", given: `{"rules": {"Tabs": {"options": {"width": 2}}}}`},
+		{description: "option of the wrong type", given: `{"rules": {"Tabs": {"options": {"tab-width": "wide"}}}}`},
+		{description: "invalid option", given: `{"rules": {"Tabs": {"options": {"tab-width": 0}}}}`},
+		{description: "unsupported charset", given: `{"charset": "latin1"}`},
+		{description: "invalid json", given: `{"rules": `},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			path := filepath.Join(dir, FileName)
+			write(t, path, test.given)
+			if _, err := Read(path, lookup); err == nil {
+				t.Errorf("Got no error but wanted one")
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package csfmt
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/revolvingcow/csfmt/diff"
+)
+
+// Severity is how seriously a violation of a rule should be taken.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Diagnostic describes a single place where a file breaks a rule.
+type Diagnostic struct {
+	Path     string
+	Line     int
+	Column   int
+	Rule     *Rule
+	Severity Severity
+	Message  string
+	Fix      *Edit
+}
+
+func (d Diagnostic) String() string {
+	rule := d.Rule.ID
+	if d.Rule.Code != "" {
+		rule = d.Rule.Code + " " + rule
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", d.Path, d.Line, d.Column, d.Severity, d.Message, rule)
+}
+
+// Check reports every violation of the rule within the source of the file
+// found at path, each with the edit which would fix it.
+func (r *Rule) Check(path string, source []byte) ([]Diagnostic, error) {
+	var edits []Edit
+	if r.Edit != nil {
+		edits = r.Edit(NewFile(path, source))
+	} else {
+		edits = r.applyEdits(source)
+	}
+	edits = unsuppressed(edits, r.suppressions(source))
+
+	diagnostics := []Diagnostic{}
+	for i := range edits {
+		edit := edits[i]
+		if edit.Start < 0 || edit.End < edit.Start || edit.End > len(source) {
+			return nil, fmt.Errorf("%s: edit %d:%d is outside of the source", r.ID, edit.Start, edit.End)
+		}
+
+		line, column := Position(source, edit.Start)
+		diagnostics = append(diagnostics, Diagnostic{
+			Path:     path,
+			Line:     line,
+			Column:   column,
+			Rule:     r,
+			Severity: SeverityError,
+			Message:  describe(source[edit.Start:edit.End], edit.Text),
+			Fix:      &edit,
+		})
+	}
+	return diagnostics, nil
+}
+
+// Changes works out the edits turning the original source into the
+// formatted source. Changed lines are narrowed down to the bytes which
+// actually differ so each edit points at the offending text.
+func Changes(original, formatted []byte) []Edit {
+	a, b := diff.Split(original), diff.Split(formatted)
+
+	offsets := func(lines [][]byte) []int {
+		offset := 0
+		starts := make([]int, len(lines)+1)
+		for i, line := range lines {
+			starts[i] = offset
+			offset += len(line)
+		}
+		starts[len(lines)] = offset
+		return starts
+	}
+	startsA, startsB := offsets(a), offsets(b)
+
+	edits := []Edit{}
+	narrow := func(start int, removed, added []byte) {
+		prefix := 0
+		for prefix < len(removed) && prefix < len(added) && removed[prefix] == added[prefix] {
+			prefix++
+		}
+		suffix := 0
+		for suffix < len(removed)-prefix && suffix < len(added)-prefix &&
+			removed[len(removed)-1-suffix] == added[len(added)-1-suffix] {
+			suffix++
+		}
+
+		edits = append(edits, Edit{
+			Start: start + prefix,
+			End:   start + len(removed) - suffix,
+			Text:  append([]byte{}, added[prefix:len(added)-suffix]...),
+		})
+	}
+	for _, change := range diff.Lines(a, b) {
+		if change.Deleted == change.Inserted {
+			for i := 0; i < change.Deleted; i++ {
+				narrow(startsA[change.A+i], a[change.A+i], b[change.B+i])
+			}
+			continue
+		}
+		removed := original[startsA[change.A]:startsA[change.A+change.Deleted]]
+		added := formatted[startsB[change.B]:startsB[change.B+change.Inserted]]
+		narrow(startsA[change.A], removed, added)
+	}
+	return edits
+}
+
+// Position converts a byte offset into a 1-based line and column, counting
+// columns in runes.
+func Position(source []byte, offset int) (int, int) {
+	if offset > len(source) {
+		offset = len(source)
+	}
+	before := source[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	start := bytes.LastIndexByte(before, '\n') + 1
+	return line, utf8.RuneCount(before[start:]) + 1
+}
+
+// describe summarizes an edit in a few words.
+func describe(removed, added []byte) string {
+	switch {
+	case len(removed) == 0:
+		return fmt.Sprintf("insert %s", quote(added))
+	case len(added) == 0:
+		return fmt.Sprintf("remove %s", quote(removed))
+	}
+	return fmt.Sprintf("replace %s with %s", quote(removed), quote(added))
+}
+
+func quote(text []byte) string {
+	const limit = 40
+	if utf8.RuneCount(text) > limit {
+		runes := []rune(string(text))
+		return fmt.Sprintf("%q...", string(runes[:limit]))
+	}
+	return fmt.Sprintf("%q", text)
+}
@@ -0,0 +1,50 @@
+package csfmt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChanges(t *testing.T) {
+	tests := []struct {
+		description string
+		given       [2]string
+		expected    []Edit
+	}{
+		{description: "nothing changed", given: [2]string{"a;\nb;\n", "a;\nb;\n"}, expected: []Edit{}},
+		{description: "insertion within a line", given: [2]string{"F(a,b);\n", "F(a, b);\n"}, expected: []Edit{{Start: 4, End: 4, Text: []byte(" ")}}},
+		{description: "removal across lines", given: [2]string{"a\n\n\nb\n", "a\nb\n"}, expected: []Edit{{Start: 2, End: 4, Text: []byte{}}}},
+		{
+			description: "several lines changed",
+			given:       [2]string{"\tx;\ny;\n\tz;\n", "    x;\ny;\n    z;\n"},
+			expected: []Edit{
+				{Start: 0, End: 1, Text: []byte("    ")},
+				{Start: 7, End: 8, Text: []byte("    ")},
+			},
+		},
+		{
+			description: "neighbouring lines changed",
+			given:       [2]string{"\tx;\n\ty;\n", "    x;\n    y;\n"},
+			expected: []Edit{
+				{Start: 0, End: 1, Text: []byte("    ")},
+				{Start: 4, End: 5, Text: []byte("    ")},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual := Changes([]byte(test.given[0]), []byte(test.given[1]))
+			if !reflect.DeepEqual(test.expected, actual) {
+				t.Errorf("Got `%v` but wanted `%v`", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestPosition(t *testing.T) {
+	line, column := Position([]byte("a\r\nbé c"), 7)
+	if line != 2 || column != 4 {
+		t.Errorf("Got %d:%d but wanted 2:4", line, column)
+	}
+}
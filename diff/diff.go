@@ -0,0 +1,200 @@
+// Package diff finds the differences between two versions of a source file.
+package diff
+
+import (
+	"bytes"
+)
+
+// Change describes a run of lines which differ between two versions. Lines
+// A up to A+Deleted of the original were replaced by lines B up to
+// B+Inserted of the new version.
+type Change struct {
+	A        int
+	B        int
+	Deleted  int
+	Inserted int
+}
+
+// Split breaks the source into lines, keeping the line endings attached so
+// that joining the lines gives back the source.
+func Split(source []byte) [][]byte {
+	lines := [][]byte{}
+	for len(source) > 0 {
+		end := bytes.IndexByte(source, '\n')
+		if end < 0 {
+			end = len(source) - 1
+		}
+		lines = append(lines, source[:end+1])
+		source = source[end+1:]
+	}
+	return lines
+}
+
+// Lines compares two sets of lines using the Myers algorithm and returns the
+// smallest set of changes turning a into b.
+func Lines(a, b [][]byte) []Change {
+	ids := map[string]int{}
+	number := func(lines [][]byte) []int {
+		numbered := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[string(line)]
+			if !ok {
+				id = len(ids)
+				ids[string(line)] = id
+			}
+			numbered[i] = id
+		}
+		return numbered
+	}
+	d := &differ{a: number(a), b: number(b)}
+	d.deleted = make([]bool, len(a))
+	d.inserted = make([]bool, len(b))
+	d.discard()
+
+	size := 2*((len(d.a)+len(d.b)+1)/2) + 3
+	d.forward, d.backward = make([]int, size), make([]int, size)
+	d.compare(0, len(d.a), 0, len(d.b))
+	return d.changes()
+}
+
+// differ holds the lines being compared, as numbers, along with which of
+// them were deleted from a or inserted into b.
+type differ struct {
+	a, b              []int
+	deleted, inserted []bool
+
+	// Lines left to search once those which cannot match are discarded,
+	// along with where they came from.
+	fromA, fromB []int
+
+	// Furthest reaching paths of the search from either end
+	forward, backward []int
+}
+
+// discard marks every line found in only one version as changed, keeping
+// the rest to be searched.
+func (d *differ) discard() {
+	inA, inB := map[int]bool{}, map[int]bool{}
+	for _, id := range d.a {
+		inA[id] = true
+	}
+	for _, id := range d.b {
+		inB[id] = true
+	}
+
+	keep := func(lines []int, other map[int]bool, changed []bool) ([]int, []int) {
+		kept, from := []int{}, []int{}
+		for i, id := range lines {
+			if !other[id] {
+				changed[i] = true
+				continue
+			}
+			kept = append(kept, id)
+			from = append(from, i)
+		}
+		return kept, from
+	}
+	d.a, d.fromA = keep(d.a, inB, d.deleted)
+	d.b, d.fromB = keep(d.b, inA, d.inserted)
+}
+
+// compare finds the changes between lines aLo up to aHi of a and lines bLo
+// up to bHi of b.
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		aLo, bLo = aLo+1, bLo+1
+	}
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi, bHi = aHi-1, bHi-1
+	}
+
+	switch {
+	case aLo == aHi:
+		for ; bLo < bHi; bLo++ {
+			d.inserted[d.fromB[bLo]] = true
+		}
+	case bLo == bHi:
+		for ; aLo < aHi; aLo++ {
+			d.deleted[d.fromA[aLo]] = true
+		}
+	default:
+		x, y, u, v := d.middle(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		d.compare(u, aHi, v, bHi)
+	}
+}
+
+// middle returns where the middle snake of the stretches starts and ends.
+// The stretches must differ at both ends.
+func (d *differ) middle(aLo, aHi, bLo, bHi int) (int, int, int, int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	max := (n + m + 1) / 2
+	offset := max + 1
+	forward, backward := d.forward, d.backward
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	for step := 0; step <= max; step++ {
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x, y = x+1, y+1
+			}
+			forward[offset+k] = x
+
+			if back := delta - k; odd && back >= -(step-1) && back <= step-1 && x+backward[offset+back] >= n {
+				return aLo + startX, bLo + startY, aLo + x, bLo + y
+			}
+		}
+
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && d.a[aHi-x-1] == d.b[bHi-y-1] {
+				x, y = x+1, y+1
+			}
+			backward[offset+k] = x
+
+			if ahead := delta - k; !odd && ahead >= -step && ahead <= step && x+forward[offset+ahead] >= n {
+				return aHi - x, bHi - y, aHi - startX, bHi - startY
+			}
+		}
+	}
+	panic("diff: no middle snake")
+}
+
+// changes groups the deleted and inserted lines into runs.
+func (d *differ) changes() []Change {
+	changes := []Change{}
+	a, b := 0, 0
+	for a < len(d.deleted) || b < len(d.inserted) {
+		if a < len(d.deleted) && b < len(d.inserted) && !d.deleted[a] && !d.inserted[b] {
+			a, b = a+1, b+1
+			continue
+		}
+		change := Change{A: a, B: b}
+		for a < len(d.deleted) && d.deleted[a] {
+			a++
+		}
+		for b < len(d.inserted) && d.inserted[b] {
+			b++
+		}
+		change.Deleted, change.Inserted = a-change.A, b-change.B
+		changes = append(changes, change)
+	}
+	return changes
+}
@@ -0,0 +1,124 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		description string
+		given       [2]string
+		expected    []Change
+	}{
+		{description: "identical", given: [2]string{"a\nb\n", "a\nb\n"}, expected: []Change{}},
+		{description: "both empty", given: [2]string{"", ""}, expected: []Change{}},
+		{description: "modified line", given: [2]string{"a\nb\nc\n", "a\nB\nc\n"}, expected: []Change{{A: 1, B: 1, Deleted: 1, Inserted: 1}}},
+		{description: "inserted lines", given: [2]string{"a\nc\n", "a\nb\nb\nc\n"}, expected: []Change{{A: 1, B: 1, Deleted: 0, Inserted: 2}}},
+		{description: "deleted lines", given: [2]string{"a\nb\nc\n", "c\n"}, expected: []Change{{A: 0, B: 0, Deleted: 2, Inserted: 0}}},
+		{description: "from nothing", given: [2]string{"", "a\n"}, expected: []Change{{A: 0, B: 0, Deleted: 0, Inserted: 1}}},
+		{
+			description: "several changes",
+			given:       [2]string{"a\nb\nc\nd\ne\n", "x\nb\nc\ne\ny\n"},
+			expected: []Change{
+				{A: 0, B: 0, Deleted: 1, Inserted: 1},
+				{A: 3, B: 3, Deleted: 1, Inserted: 0},
+				{A: 5, B: 4, Deleted: 0, Inserted: 1},
+			},
+		},
+		{description: "missing final newline", given: [2]string{"a\nb", "a\nb\n"}, expected: []Change{{A: 1, B: 1, Deleted: 1, Inserted: 1}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual := Lines(Split([]byte(test.given[0])), Split([]byte(test.given[1])))
+			if !reflect.DeepEqual(test.expected, actual) {
+				t.Errorf("Got `%v` but wanted `%v`", actual, test.expected)
+			}
+		})
+	}
+}
+
+// patch applies the changes to a, taking inserted lines from b.
+func patch(a, b [][]byte, changes []Change) [][]byte {
+	patched := [][]byte{}
+	next := 0
+	for _, change := range changes {
+		patched = append(patched, a[next:change.A]...)
+		patched = append(patched, b[change.B:change.B+change.Inserted]...)
+		next = change.A + change.Deleted
+	}
+	return append(patched, a[next:]...)
+}
+
+// distance is the fewest lines deleted and inserted to turn a into b.
+func distance(a, b [][]byte) int {
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case bytes.Equal(a[i], b[j]):
+				common[i][j] = common[i+1][j+1] + 1
+			case common[i+1][j] > common[i][j+1]:
+				common[i][j] = common[i+1][j]
+			default:
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+	return len(a) + len(b) - 2*common[0][0]
+}
+
+func TestLinesShortest(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	file := func() [][]byte {
+		lines := [][]byte{}
+		for i := random.Intn(12); i > 0; i-- {
+			lines = append(lines, []byte(string(rune('a'+random.Intn(4)))+"\n"))
+		}
+		return lines
+	}
+
+	for i := 0; i < 2000; i++ {
+		a, b := file(), file()
+		changes := Lines(a, b)
+		if patched := patch(a, b, changes); !reflect.DeepEqual(bytes.Join(patched, nil), bytes.Join(b, nil)) {
+			t.Fatalf("Got `%q` from `%q` but wanted `%q`", patched, a, b)
+		}
+		edits := 0
+		for _, change := range changes {
+			edits += change.Deleted + change.Inserted
+		}
+		if expected := distance(a, b); edits != expected {
+			t.Fatalf("Got %d edits turning `%q` into `%q` but wanted %d", edits, a, b, expected)
+		}
+	}
+}
+
+func TestLinesLarge(t *testing.T) {
+	original, formatted := &bytes.Buffer{}, &bytes.Buffer{}
+	for i := 0; i < 15000; i++ {
+		fmt.Fprintf(original, "\tif (x%d)\n\t{\n\t\ty = %d;\n\t}\n\n", i, i)
+		fmt.Fprintf(formatted, "    if (x%d)\n    {\n        y = %d;\n    }\n\n", i, i)
+	}
+	a, b := Split(original.Bytes()), Split(formatted.Bytes())
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	changes := Lines(a, b)
+	runtime.ReadMemStats(&after)
+
+	if patched := patch(a, b, changes); !bytes.Equal(bytes.Join(patched, nil), formatted.Bytes()) {
+		t.Errorf("Got a different file after applying the changes")
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 64<<20 {
+		t.Errorf("Got %d bytes allocated but wanted at most %d", allocated, 64<<20)
+	}
+}
@@ -0,0 +1,84 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Unified formats the differences between two versions of a file in the
+// unified format understood by patch, surrounding each change with the
+// given number of lines of context. Nothing is returned when the versions
+// are identical.
+func Unified(nameA, nameB string, a, b []byte, context int) []byte {
+	linesA, linesB := Split(a), Split(b)
+	changes := Lines(linesA, linesB)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "--- %s\n", nameA)
+	fmt.Fprintf(out, "+++ %s\n", nameB)
+
+	for len(changes) > 0 {
+		// Gather every change close enough to share context with the first
+		n := 1
+		for n < len(changes) && changes[n].A-(changes[n-1].A+changes[n-1].Deleted) <= 2*context {
+			n++
+		}
+		first, last := changes[0], changes[n-1]
+
+		startA := first.A - context
+		if startA < 0 {
+			startA = 0
+		}
+		startB := first.B - (first.A - startA)
+		endA := last.A + last.Deleted + context
+		if endA > len(linesA) {
+			endA = len(linesA)
+		}
+		endB := last.B + last.Inserted + (endA - (last.A + last.Deleted))
+
+		fmt.Fprintf(out, "@@ -%s +%s @@\n", span(startA, endA-startA), span(startB, endB-startB))
+		a := startA
+		for _, change := range changes[:n] {
+			for ; a < change.A; a++ {
+				line(out, ' ', linesA[a])
+			}
+			for i := change.A; i < change.A+change.Deleted; i++ {
+				line(out, '-', linesA[i])
+			}
+			for i := change.B; i < change.B+change.Inserted; i++ {
+				line(out, '+', linesB[i])
+			}
+			a = change.A + change.Deleted
+		}
+		for ; a < endA; a++ {
+			line(out, ' ', linesA[a])
+		}
+
+		changes = changes[n:]
+	}
+
+	return out.Bytes()
+}
+
+// span formats the range of a hunk header. Empty ranges refer to the line
+// before the hunk and single lines leave out the length.
+func span(start, length int) string {
+	switch length {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+func line(out *bytes.Buffer, prefix byte, text []byte) {
+	out.WriteByte(prefix)
+	out.Write(text)
+	if !bytes.HasSuffix(text, []byte("\n")) {
+		out.WriteString("\n\\ No newline at end of file\n")
+	}
+}
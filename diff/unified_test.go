@@ -0,0 +1,48 @@
+package diff
+
+import (
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		description string
+		given       [2]string
+		expected    string
+	}{
+		{description: "identical", given: [2]string{"a\n", "a\n"}, expected: ""},
+		{
+			description: "single change with context",
+			given:       [2]string{"1\n2\n3\n4\n5\n6\n7\n8\n", "1\n2\n3\n4\nfive\n6\n7\n8\n"},
+			expected: "--- a.cs.orig\n+++ a.cs\n" +
+				"@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			description: "distant changes make separate hunks",
+			given:       [2]string{"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", "one\n2\n3\n4\n5\n6\n7\n8\n9\n"},
+			expected: "--- a.cs.orig\n+++ a.cs\n" +
+				"@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+				"@@ -7,4 +7,3 @@\n 7\n 8\n 9\n-10\n",
+		},
+		{
+			description: "insertion into an empty file",
+			given:       [2]string{"", "a\n"},
+			expected:    "--- a.cs.orig\n+++ a.cs\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			description: "missing newline at end of file",
+			given:       [2]string{"a\nb", "a\nb\n"},
+			expected: "--- a.cs.orig\n+++ a.cs\n" +
+				"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual := string(Unified("a.cs.orig", "a.cs", []byte(test.given[0]), []byte(test.given[1]), 3))
+			if actual != test.expected {
+				t.Errorf("Got `%s` but wanted `%s`", actual, test.expected)
+			}
+		})
+	}
+}
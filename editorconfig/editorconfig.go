@@ -0,0 +1,210 @@
+// Package editorconfig reads the .editorconfig files describing the style
+// of a project and works out the properties in effect for each file.
+package editorconfig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileName is the name of the file looked for in the directory of each
+// source file and every directory above it.
+const FileName = ".editorconfig"
+
+// File is a single parsed .editorconfig file.
+type File struct {
+	// Root stops the search for files in parent directories.
+	Root     bool
+	Sections []Section
+}
+
+// Section holds the properties applying to the files matched by its glob.
+// Property names are lower cased while values are kept as written.
+type Section struct {
+	Glob       string
+	Properties map[string]string
+}
+
+// Parse reads an .editorconfig file.
+func Parse(r io.Reader) (*File, error) {
+	f := &File{}
+	var section *Section
+
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if number == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+		case line[0] == '[':
+			end := strings.LastIndex(line, "]")
+			if end < 1 {
+				return nil, fmt.Errorf("line %d: unterminated section header", number)
+			}
+			f.Sections = append(f.Sections, Section{
+				Glob:       line[1:end],
+				Properties: map[string]string{},
+			})
+			section = &f.Sections[len(f.Sections)-1]
+		default:
+			equals := strings.IndexByte(line, '=')
+			if equals < 1 {
+				return nil, fmt.Errorf("line %d: expected a property or section", number)
+			}
+			key := strings.ToLower(strings.TrimSpace(line[:equals]))
+			value := strings.TrimSpace(line[equals+1:])
+
+			if section == nil {
+				// Only root may be set before the first section
+				if key == "root" {
+					f.Root = strings.EqualFold(value, "true")
+				}
+				continue
+			}
+			section.Properties[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Properties returns the properties the file sets for the path, given
+// relative to the directory holding the file. Later sections override
+// earlier ones.
+func (f *File) Properties(path string) (map[string]string, error) {
+	properties := map[string]string{}
+	for _, section := range f.Sections {
+		matched, err := Match(section.Glob, path)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+		for key, value := range section.Properties {
+			properties[key] = value
+		}
+	}
+	return properties, nil
+}
+
+// Loader finds the properties in effect for source files, remembering the
+// files it has already read for each directory. A configuration loader asks
+// it on behalf of every worker, so reading a directory is done under a lock.
+type Loader struct {
+	mu    sync.Mutex
+	cache map[string]*File
+}
+
+// NewLoader creates a loader with nothing read yet.
+func NewLoader() *Loader {
+	return &Loader{
+		cache: map[string]*File{},
+	}
+}
+
+// Properties returns the properties in effect for the file found at path.
+// Files are looked for from the file's directory up to the first one marked
+// as root, with files closer to the source overriding those further away.
+// Properties set to "unset" are removed.
+func (l *Loader) Properties(path string) (map[string]string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Gather every file which applies, closest first
+	dirs := []string{}
+	files := []*File{}
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		f, err := l.read(dir)
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			dirs = append(dirs, dir)
+			files = append(files, f)
+			if f.Root {
+				break
+			}
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	properties := map[string]string{}
+	for i := len(files) - 1; i >= 0; i-- {
+		rel, err := filepath.Rel(dirs[i], abs)
+		if err != nil {
+			return nil, err
+		}
+		found, err := files[i].Properties(filepath.ToSlash(rel))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", filepath.Join(dirs[i], FileName), err)
+		}
+		for key, value := range found {
+			properties[key] = value
+		}
+	}
+
+	for key, value := range properties {
+		if strings.EqualFold(value, "unset") {
+			delete(properties, key)
+		}
+	}
+	defaults(properties)
+	return properties, nil
+}
+
+func (l *Loader) read(dir string) (*File, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if f, ok := l.cache[dir]; ok {
+		return f, nil
+	}
+
+	var f *File
+	path := filepath.Join(dir, FileName)
+	r, err := os.Open(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		defer r.Close()
+		f, err = Parse(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+
+	l.cache[dir] = f
+	return f, nil
+}
+
+// defaults fills in the indentation properties which follow from each other
+// as described by the specification.
+func defaults(properties map[string]string) {
+	style, size, width := properties["indent_style"], properties["indent_size"], properties["tab_width"]
+	if strings.EqualFold(style, "tab") && size == "" {
+		size = "tab"
+		properties["indent_size"] = size
+	}
+	if size != "" && !strings.EqualFold(size, "tab") && width == "" {
+		properties["tab_width"] = size
+	}
+	if strings.EqualFold(size, "tab") && width != "" {
+		properties["indent_size"] = width
+	}
+}
@@ -0,0 +1,130 @@
+package editorconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	given := "\ufeff# top-most\nroot = true\n\n[*]\nIndent_Style = space\n; comment\n[*.{cs,csx}]\nindent_size=4\ntab_width = 8 \n"
+	expected := &File{
+		Root: true,
+		Sections: []Section{
+			{Glob: "*", Properties: map[string]string{"indent_style": "space"}},
+			{Glob: "*.{cs,csx}", Properties: map[string]string{"indent_size": "4", "tab_width": "8"}},
+		},
+	}
+
+	actual, err := Parse(strings.NewReader(given))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Got `%v` but wanted `%v`", actual, expected)
+	}
+
+	for _, invalid := range []string{"[*.cs\n", "[*]\nindent_style\n"} {
+		if _, err := Parse(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Got no error for `%s` but wanted one", invalid)
+		}
+	}
+}
+
+func TestProperties(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csfmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		FileName:                       "root = true\n[*]\nindent_style = space\ninsert_final_newline = true\n[*.cs]\nindent_size = 4\n[src/legacy/**.cs]\nindent_style = tab\n",
+		filepath.Join("src", FileName): "[*.cs]\ninsert_final_newline = unset\ndotnet_sort_system_directives_first = true\n[Generated.cs]\nindent_size = 2\n",
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		description string
+		given       string
+		expected    map[string]string
+	}{
+		{
+			description: "top level",
+			given:       "Program.cs",
+			expected:    map[string]string{"indent_style": "space", "indent_size": "4", "tab_width": "4", "insert_final_newline": "true"},
+		},
+		{
+			description: "other extensions",
+			given:       "README.md",
+			expected:    map[string]string{"indent_style": "space", "insert_final_newline": "true"},
+		},
+		{
+			description: "closer files win and unset removes",
+			given:       filepath.Join("src", "Generated.cs"),
+			expected:    map[string]string{"indent_style": "space", "indent_size": "2", "tab_width": "2", "dotnet_sort_system_directives_first": "true"},
+		},
+		{
+			description: "path globs are relative to the file",
+			given:       filepath.Join("src", "legacy", "deep", "Old.cs"),
+			expected:    map[string]string{"indent_style": "tab", "indent_size": "4", "tab_width": "4", "dotnet_sort_system_directives_first": "true"},
+		},
+	}
+
+	loader := NewLoader()
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual, err := loader.Properties(filepath.Join(dir, test.given))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(test.expected, actual) {
+				t.Errorf("Got `%v` but wanted `%v`", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	tests := []struct {
+		description string
+		given       map[string]string
+		expected    map[string]string
+	}{
+		{
+			description: "tabs indent by a tab",
+			given:       map[string]string{"indent_style": "tab"},
+			expected:    map[string]string{"indent_style": "tab", "indent_size": "tab"},
+		},
+		{
+			description: "tab width follows indent size",
+			given:       map[string]string{"indent_size": "2"},
+			expected:    map[string]string{"indent_size": "2", "tab_width": "2"},
+		},
+		{
+			description: "indent size follows tab width",
+			given:       map[string]string{"indent_style": "tab", "tab_width": "8"},
+			expected:    map[string]string{"indent_style": "tab", "indent_size": "8", "tab_width": "8"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defaults(test.given)
+			if !reflect.DeepEqual(test.expected, test.given) {
+				t.Errorf("Got `%v` but wanted `%v`", test.given, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package editorconfig
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Match reports whether the path, relative to the directory holding the
+// .editorconfig file and separated by forward slashes, matches the glob of
+// a section. Globs without a slash match files in any directory.
+func Match(glob, path string) (bool, error) {
+	switch {
+	case strings.HasPrefix(glob, "/"):
+		glob = glob[1:]
+	case !strings.Contains(glob, "/"):
+		glob = "**/" + glob
+	}
+
+	t := &translator{}
+	re, err := regexp.Compile("^" + t.translate(glob) + "$")
+	if err != nil {
+		return false, err
+	}
+
+	matches := re.FindStringSubmatchIndex(path)
+	if matches == nil {
+		return false, nil
+	}
+
+	// Numeric ranges match any number so the bounds are checked afterwards
+	for i, bounds := range t.ranges {
+		start, end := matches[2*i+2], matches[2*i+3]
+		if start < 0 {
+			// Part of an alternative which was not taken
+			continue
+		}
+		n, err := strconv.Atoi(path[start:end])
+		if err != nil || n < bounds[0] || n > bounds[1] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// translator turns a glob into a regular expression, keeping track of the
+// numeric ranges found along the way. Each range becomes the only capturing
+// group of the expression in order of appearance.
+type translator struct {
+	ranges [][2]int
+}
+
+var numericRange = regexp.MustCompile(`^([+-]?\d+)\.\.([+-]?\d+)$`)
+
+func (t *translator) translate(glob string) string {
+	out := &strings.Builder{}
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				c = glob[i]
+			}
+			literal(out, c)
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					// Allow "a/**/b" to match "a/b" as well
+					i++
+					out.WriteString("(?:.*/)?")
+				} else {
+					out.WriteString(".*")
+				}
+			} else {
+				out.WriteString("[^/]*")
+			}
+		case '?':
+			out.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 || strings.Contains(glob[i+1:i+1+end], "/") {
+				out.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			i += end + 1
+
+			out.WriteByte('[')
+			if strings.HasPrefix(class, "!") || strings.HasPrefix(class, "^") {
+				out.WriteByte('^')
+				class = class[1:]
+			}
+			for _, r := range class {
+				if r == '\\' || r == '[' || r == '^' {
+					out.WriteByte('\\')
+				}
+				out.WriteRune(r)
+			}
+			out.WriteByte(']')
+		case '{':
+			end := closingBrace(glob, i)
+			if end < 0 {
+				out.WriteString(`\{`)
+				continue
+			}
+			inner := glob[i+1 : end]
+			i = end
+
+			if bounds := numericRange.FindStringSubmatch(inner); bounds != nil {
+				low, _ := strconv.Atoi(bounds[1])
+				high, _ := strconv.Atoi(bounds[2])
+				if low > high {
+					low, high = high, low
+				}
+				t.ranges = append(t.ranges, [2]int{low, high})
+				out.WriteString(`([+-]?\d+)`)
+				continue
+			}
+
+			alternatives := splitAlternatives(inner)
+			if len(alternatives) < 2 {
+				// A single choice is not a choice at all
+				out.WriteString(`\{` + t.translate(inner) + `\}`)
+				continue
+			}
+			out.WriteString("(?:")
+			for n, alternative := range alternatives {
+				if n > 0 {
+					out.WriteByte('|')
+				}
+				out.WriteString(t.translate(alternative))
+			}
+			out.WriteByte(')')
+		default:
+			literal(out, c)
+		}
+	}
+	return out.String()
+}
+
+// literal writes a byte of the glob which has no special meaning, escaping
+// it when it would have one within the regular expression.
+func literal(out *strings.Builder, c byte) {
+	if c < utf8.RuneSelf {
+		out.WriteString(regexp.QuoteMeta(string(c)))
+		return
+	}
+	out.WriteByte(c)
+}
+
+// closingBrace finds the brace closing the one opened at start, allowing
+// for nested braces and escapes.
+func closingBrace(glob string, start int) int {
+	depth := 0
+	for i := start; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitAlternatives splits the inside of braces on the commas which are not
+// nested within further braces.
+func splitAlternatives(inner string) []string {
+	alternatives := []string{}
+	depth, start := 0, 0
+	for i := 0; i < len(inner); i++ {
+		switch inner[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				alternatives = append(alternatives, inner[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(alternatives, inner[start:])
+}
@@ -0,0 +1,56 @@
+package editorconfig
+
+import (
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		glob     string
+		path     string
+		expected bool
+	}{
+		{glob: "*", path: "Program.cs", expected: true},
+		{glob: "*", path: "src/Program.cs", expected: true},
+		{glob: "*.cs", path: "src/deep/Program.cs", expected: true},
+		{glob: "*.cs", path: "Program.csx", expected: false},
+		{glob: "/*.cs", path: "src/Program.cs", expected: false},
+		{glob: "/*.cs", path: "Program.cs", expected: true},
+		{glob: "src/*.cs", path: "src/Program.cs", expected: true},
+		{glob: "src/*.cs", path: "src/deep/Program.cs", expected: false},
+		{glob: "src/**.cs", path: "src/deep/Program.cs", expected: true},
+		{glob: "src/**/*.cs", path: "src/Program.cs", expected: true},
+		{glob: "src/**/*.cs", path: "lib/src/Program.cs", expected: false},
+		{glob: "Program.?s", path: "Program.cs", expected: true},
+		{glob: "Program.?s", path: "Program.s", expected: false},
+		{glob: "*.[ch]s", path: "a.hs", expected: true},
+		{glob: "*.[!ch]s", path: "a.hs", expected: false},
+		{glob: "*.[!ch]s", path: "a.js", expected: true},
+		{glob: "*.{cs,csx}", path: "a.csx", expected: true},
+		{glob: "*.{cs,csx}", path: "a.vb", expected: false},
+		{glob: "*.{cs,{vb,fs}}", path: "a.fs", expected: true},
+		{glob: "{single}.cs", path: "{single}.cs", expected: true},
+		{glob: "{single}.cs", path: "single.cs", expected: false},
+		{glob: "file{1..3}.cs", path: "file2.cs", expected: true},
+		{glob: "file{1..3}.cs", path: "file4.cs", expected: false},
+		{glob: "file{-3..-1}.cs", path: "file-2.cs", expected: true},
+		{glob: "{a,file{1..3}}.cs", path: "a.cs", expected: true},
+		{glob: `\*.cs`, path: "*.cs", expected: true},
+		{glob: `\*.cs`, path: "a.cs", expected: false},
+		{glob: "a+b(c).cs", path: "a+b(c).cs", expected: true},
+		{glob: "[a/b].cs", path: "[a/b].cs", expected: true},
+		{glob: "Ünïcode.cs", path: "Ünïcode.cs", expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.glob+" "+test.path, func(t *testing.T) {
+			actual, err := Match(test.glob, test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != test.expected {
+				t.Errorf("Got `%t` but wanted `%t`", actual, test.expected)
+			}
+		})
+	}
+}
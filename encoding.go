@@ -0,0 +1,129 @@
+package csfmt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is how the text of a source file is stored.
+type Encoding string
+
+const (
+	UTF8    Encoding = "utf-8"
+	UTF8BOM Encoding = "utf-8-bom"
+	UTF16LE Encoding = "utf-16le"
+	UTF16BE Encoding = "utf-16be"
+)
+
+// byteOrderMarks lists the bytes found at the start of files in each
+// encoding which has them.
+var byteOrderMarks = []struct {
+	encoding Encoding
+	mark     []byte
+}{
+	{UTF8BOM, []byte{0xEF, 0xBB, 0xBF}},
+	{UTF16LE, []byte{0xFF, 0xFE}},
+	{UTF16BE, []byte{0xFE, 0xFF}},
+}
+
+// ParseEncoding checks the name is one of the supported encodings.
+func ParseEncoding(name string) (Encoding, error) {
+	encoding := Encoding(strings.ToLower(strings.TrimSpace(name)))
+	switch encoding {
+	case UTF8, UTF8BOM, UTF16LE, UTF16BE:
+		return encoding, nil
+	}
+	return "", fmt.Errorf("unsupported encoding %q", name)
+}
+
+// Decode converts the contents of a source file to UTF-8 without a byte
+// order mark, returning the encoding they were found in.
+func Decode(raw []byte) ([]byte, Encoding, error) {
+	// UTF-32 starts the same as UTF-16 so it has to be ruled out first
+	if bytes.HasPrefix(raw, []byte{0xFF, 0xFE, 0, 0}) || bytes.HasPrefix(raw, []byte{0, 0, 0xFE, 0xFF}) {
+		return nil, "", fmt.Errorf("UTF-32 is not supported")
+	}
+
+	encoding := UTF8
+	for _, bom := range byteOrderMarks {
+		if bytes.HasPrefix(raw, bom.mark) {
+			encoding = bom.encoding
+			raw = raw[len(bom.mark):]
+			break
+		}
+	}
+
+	switch encoding {
+	case UTF16LE, UTF16BE:
+		return decodeUTF16(raw, encoding)
+	}
+	if bytes.IndexByte(raw, 0) >= 0 {
+		return nil, "", fmt.Errorf("contains NUL bytes, which is either not text or UTF-16 without a byte order mark")
+	}
+	if !utf8.Valid(raw) {
+		return nil, "", fmt.Errorf("is not valid UTF-8 and has no byte order mark saying otherwise")
+	}
+	return raw, encoding, nil
+}
+
+func decodeUTF16(raw []byte, encoding Encoding) ([]byte, Encoding, error) {
+	if len(raw)%2 != 0 {
+		return nil, "", fmt.Errorf("ends part way through a %s character", encoding)
+	}
+
+	units := make([]rune, len(raw)/2)
+	for i := range units {
+		if encoding == UTF16LE {
+			units[i] = rune(raw[2*i]) | rune(raw[2*i+1])<<8
+		} else {
+			units[i] = rune(raw[2*i])<<8 | rune(raw[2*i+1])
+		}
+	}
+
+	decoded := make([]byte, 0, len(raw))
+	buffer := make([]byte, utf8.UTFMax)
+	for i := 0; i < len(units); i++ {
+		r := units[i]
+		if utf16.IsSurrogate(r) {
+			r = utf8.RuneError
+			if i+1 < len(units) {
+				r = utf16.DecodeRune(units[i], units[i+1])
+				i++
+			}
+			if r == utf8.RuneError {
+				return nil, "", fmt.Errorf("is not valid %s", encoding)
+			}
+		}
+		decoded = append(decoded, buffer[:utf8.EncodeRune(buffer, r)]...)
+	}
+	return decoded, encoding, nil
+}
+
+// Encode converts UTF-8 text to the encoding. Text without an encoding is
+// left as UTF-8.
+func Encode(text []byte, encoding Encoding) ([]byte, error) {
+	encoded := []byte{}
+	for _, bom := range byteOrderMarks {
+		if bom.encoding == encoding {
+			encoded = append(encoded, bom.mark...)
+		}
+	}
+
+	switch encoding {
+	case "", UTF8, UTF8BOM:
+		return append(encoded, text...), nil
+	case UTF16LE, UTF16BE:
+		for _, unit := range utf16.Encode([]rune(string(text))) {
+			if encoding == UTF16LE {
+				encoded = append(encoded, byte(unit), byte(unit>>8))
+			} else {
+				encoded = append(encoded, byte(unit>>8), byte(unit))
+			}
+		}
+		return encoded, nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", encoding)
+}
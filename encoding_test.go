@@ -0,0 +1,63 @@
+package csfmt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		encoding Encoding
+		raw      []byte
+		text     string
+	}{
+		{encoding: UTF8, raw: []byte("// Ünïcode 𝄞\r\n"), text: "// Ünïcode 𝄞\r\n"},
+		{encoding: UTF8BOM, raw: []byte("\xEF\xBB\xBFclass A {}\n"), text: "class A {}\n"},
+		{encoding: UTF16LE, raw: []byte{0xFF, 0xFE, 'a', 0, '\n', 0, 0x34, 0xD8, 0x1E, 0xDD}, text: "a\n𝄞"},
+		{encoding: UTF16BE, raw: []byte{0xFE, 0xFF, 0, 'a', 0, '\n', 0xD8, 0x34, 0xDD, 0x1E}, text: "a\n𝄞"},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.encoding), func(t *testing.T) {
+			decoded, encoding, err := Decode(test.raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if encoding != test.encoding {
+				t.Errorf("Got `%s` but wanted `%s`", encoding, test.encoding)
+			}
+			if string(decoded) != test.text {
+				t.Errorf("Got `%q` but wanted `%q`", decoded, test.text)
+			}
+
+			encoded, err := Encode(decoded, encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(test.raw, encoded) {
+				t.Errorf("Got `%v` but wanted `%v`", encoded, test.raw)
+			}
+		})
+	}
+}
+
+func TestDecodeRefuses(t *testing.T) {
+	tests := []struct {
+		description string
+		raw         []byte
+	}{
+		{description: "latin1", raw: []byte("caf\xe9")},
+		{description: "utf-16 without a byte order mark", raw: []byte{'a', 0, '\n', 0}},
+		{description: "utf-32", raw: []byte{0xFF, 0xFE, 0, 0, 'a', 0, 0, 0}},
+		{description: "odd length utf-16", raw: []byte{0xFF, 0xFE, 'a'}},
+		{description: "unpaired surrogate", raw: []byte{0xFF, 0xFE, 0x34, 0xD8, 'a', 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if _, _, err := Decode(test.raw); err == nil {
+				t.Errorf("Got no error but wanted one")
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+// Package git asks the local git which files of a repository changed.
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/revolvingcow/csfmt"
+)
+
+// Changed returns the files changed in the working tree or the index since
+// the last commit, along with new files git does not know about yet. Paths
+// limit the files to those beneath them.
+func Changed(dir string, paths ...string) ([]string, error) {
+	base := "HEAD"
+	if _, err := run(dir, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		// Nothing committed yet so everything in the index is new
+		base = "--cached"
+	}
+	changed, err := run(dir, append([]string{"diff", "--name-only", "-z", "--diff-filter=d", base, "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := run(dir, append([]string{"ls-files", "-z", "--others", "--exclude-standard", "--full-name", "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	return existing(dir, append(changed, untracked...))
+}
+
+// Staged returns the files changed in the index since the last commit.
+func Staged(dir string, paths ...string) ([]string, error) {
+	staged, err := run(dir, append([]string{"diff", "--cached", "--name-only", "-z", "--diff-filter=d", "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	return existing(dir, staged)
+}
+
+// Since returns the files changed since the current branch left the
+// revision, including those not committed yet.
+func Since(dir, revision string, paths ...string) ([]string, error) {
+	base, err := MergeBase(dir, revision)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := run(dir, append([]string{"diff", "--name-only", "-z", "--diff-filter=d", base, "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	return existing(dir, changed)
+}
+
+// MergeBase returns the commit where the current branch left the revision.
+func MergeBase(dir, revision string) (string, error) {
+	base, err := run(dir, "merge-base", revision, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if len(base) != 1 {
+		return "", fmt.Errorf("git merge-base: no common ancestor with %s", revision)
+	}
+	return base[0], nil
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// hunk is where a hunk of a diff without any context is found in the old
+// and the new version of a file. Without any lines a hunk starts after the
+// line it gives.
+type hunk struct {
+	oldStart, oldCount int
+	newStart, newCount int
+}
+
+// hunks returns the hunks of a diff without any context.
+func hunks(diff []string) []hunk {
+	found := []hunk{}
+	for _, line := range diff {
+		match := hunkHeader.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		number := func(i int) int {
+			if match[i] == "" {
+				return 1
+			}
+			n, _ := strconv.Atoi(match[i])
+			return n
+		}
+		found = append(found, hunk{number(1), number(2), number(3), number(4)})
+	}
+	return found
+}
+
+// moved returns the lines, numbered as in the old version of a file, as
+// they are numbered in the new version after the changes.
+func moved(lines []csfmt.LineRange, changes []hunk) []csfmt.LineRange {
+	line := func(n int, end bool) int {
+		offset := 0
+		for _, h := range changes {
+			if h.oldCount == 0 {
+				if n <= h.oldStart {
+					break
+				}
+				offset += h.newCount
+				continue
+			}
+			if n < h.oldStart {
+				break
+			}
+			if n < h.oldStart+h.oldCount {
+				// The line was changed, so take what replaced it
+				switch {
+				case end:
+					return h.newStart + h.newCount - 1
+				case h.newCount == 0:
+					return h.newStart + 1
+				}
+				return h.newStart
+			}
+			offset += h.newCount - h.oldCount
+		}
+		return n + offset
+	}
+
+	found := []csfmt.LineRange{}
+	for _, r := range lines {
+		if start, end := line(r.Start, false), line(r.End, true); start <= end {
+			found = append(found, csfmt.LineRange{Start: start, End: end})
+		}
+	}
+	return found
+}
+
+// Lines returns the lines of the file found at path which changed, in the
+// working tree or the index since the last commit, in the index alone when
+// staged, or since the current branch left the revision when one is given.
+func Lines(path string, staged bool, revision string) ([]csfmt.LineRange, error) {
+	dir, name := filepath.Dir(path), filepath.Base(path)
+	whole := []csfmt.LineRange{{Start: 1, End: math.MaxInt32}}
+	if _, err := run(dir, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return whole, nil
+	}
+	if _, err := run(dir, "ls-files", "--error-unmatch", "--", name); err != nil {
+		return whole, nil
+	}
+
+	args := []string{"diff", "-U0", "--no-color", "--no-ext-diff"}
+	switch {
+	case staged:
+		args = append(args, "--cached")
+	case revision != "":
+		base, err := MergeBase(dir, revision)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, base)
+	default:
+		args = append(args, "HEAD")
+	}
+	out, err := run(dir, append(args, "--", name)...)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []csfmt.LineRange{}
+	for _, h := range hunks(out) {
+		if h.newCount > 0 {
+			lines = append(lines, csfmt.LineRange{Start: h.newStart, End: h.newStart + h.newCount - 1})
+		}
+	}
+	if !staged {
+		return lines, nil
+	}
+
+	unstaged, err := run(dir, "diff", "-U0", "--no-color", "--no-ext-diff", "--", name)
+	if err != nil {
+		return nil, err
+	}
+	return moved(lines, hunks(unstaged)), nil
+}
+
+// Unstaged returns the files whose working tree differs from the index.
+func Unstaged(dir string, paths ...string) ([]string, error) {
+	unstaged, err := run(dir, append([]string{"diff", "--name-only", "-z", "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	return existing(dir, unstaged)
+}
+
+// ReadIndex returns the contents staged for the file found at path.
+func ReadIndex(path string) ([]byte, error) {
+	return output(filepath.Dir(path), nil, "cat-file", "blob", ":./"+filepath.Base(path))
+}
+
+// WriteIndex stages the contents for the file found at path without
+// touching the file itself.
+func WriteIndex(path string, contents []byte) error {
+	dir := filepath.Dir(path)
+	staged, err := run(dir, "ls-files", "--stage", "--full-name", "--", filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if len(staged) != 1 {
+		return fmt.Errorf("git ls-files: %s is not staged", path)
+	}
+	// Entries look like "100644 <object> 0\t<path>"
+	entry := strings.SplitN(staged[0], "\t", 2)
+	fields := strings.Fields(entry[0])
+	if len(entry) != 2 || len(fields) != 3 {
+		return fmt.Errorf("git ls-files: unexpected entry %q", staged[0])
+	}
+
+	object, err := output(dir, contents, "hash-object", "-w", "--no-filters", "--stdin")
+	if err != nil {
+		return err
+	}
+	_, err = run(dir, "update-index", "--cacheinfo", fields[0]+","+strings.TrimSpace(string(object))+","+entry[1])
+	return err
+}
+
+// HooksDir returns the directory git runs the hooks of the repository from.
+func HooksDir(dir string) (string, error) {
+	hooks, err := run(dir, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	if len(hooks) != 1 {
+		return "", fmt.Errorf("git rev-parse: no hooks directory")
+	}
+	if filepath.IsAbs(hooks[0]) {
+		return hooks[0], nil
+	}
+	return filepath.Join(dir, hooks[0]), nil
+}
+
+// existing returns the paths, given from the top of the repository, of the
+// files which still exist.
+func existing(dir string, paths []string) ([]string, error) {
+	top, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	if len(top) != 1 {
+		return nil, fmt.Errorf("git rev-parse: no top level directory")
+	}
+
+	seen := map[string]bool{}
+	files := []string{}
+	for _, path := range paths {
+		path = filepath.Join(top[0], filepath.FromSlash(path))
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+// run runs git in the directory, returning what it printed split into
+// paths or lines.
+func run(dir string, args ...string) ([]string, error) {
+	out, err := output(dir, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	separator := "\n"
+	if bytes.IndexByte(out, 0) >= 0 {
+		separator = "\x00"
+	}
+	found := []string{}
+	for _, line := range strings.Split(string(out), separator) {
+		if line = strings.TrimSuffix(line, "\n"); line != "" {
+			found = append(found, line)
+		}
+	}
+	return found, nil
+}
+
+// output runs git in the directory with the input, returning exactly what
+// it printed.
+func output(dir string, input []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = bytes.NewReader(input)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return nil, fmt.Errorf("git %s: %s", args[0], err)
+	}
+	return out, nil
+}
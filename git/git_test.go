@@ -0,0 +1,193 @@
+package git
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/revolvingcow/csfmt"
+)
+
+func TestChanged(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "csfmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version := 0
+	write := func(name string) {
+		version++
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(fmt.Sprintf("// %d\n", version)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git := func(args ...string) {
+		args = append([]string{"-c", "user.name=csfmt", "-c", "user.email=csfmt@example.com", "-c", "commit.gpgsign=false"}, args...)
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+	check := func(description string, expected []string, found []string, err error) {
+		if err != nil {
+			t.Fatalf("%s: %s", description, err)
+		}
+		actual := []string{}
+		for _, path := range found {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual = append(actual, filepath.ToSlash(rel))
+		}
+		sort.Strings(actual)
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("%s: got `%v` but wanted `%v`", description, actual, expected)
+		}
+	}
+
+	git("init", "-q")
+	for _, name := range []string{"Main.cs", "Staged.cs", "Moved.cs", "Gone.cs", "src/Deep.cs"} {
+		write(name)
+	}
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+	git("branch", "base")
+
+	write("Main.cs")
+	git("commit", "-q", "-am", "second")
+
+	write("Staged.cs")
+	write("src/Deep.cs")
+	write("New.cs")
+	write("src/Untracked.cs")
+	git("add", "Staged.cs", "New.cs")
+	git("mv", "Moved.cs", "Renamed.cs")
+	git("rm", "-q", "Gone.cs")
+
+	found, err := Changed(dir)
+	check("changed", []string{"New.cs", "Renamed.cs", "Staged.cs", "src/Deep.cs", "src/Untracked.cs"}, found, err)
+	found, err = Changed(filepath.Join(dir, "src"), ".")
+	check("changed beneath a path", []string{"src/Deep.cs", "src/Untracked.cs"}, found, err)
+	found, err = Staged(dir)
+	check("staged", []string{"New.cs", "Renamed.cs", "Staged.cs"}, found, err)
+	found, err = Since(dir, "base")
+	check("since", []string{"Main.cs", "New.cs", "Renamed.cs", "Staged.cs", "src/Deep.cs"}, found, err)
+
+	if _, err := Since(dir, "missing"); err == nil {
+		t.Errorf("Got no error for an unknown revision but wanted one")
+	}
+
+	// Lines of a file changing in several places
+	if err := ioutil.WriteFile(filepath.Join(dir, "Lines.cs"), []byte("1\n2\n3\n4\n5\n6\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "Lines.cs")
+	git("commit", "-q", "-m", "third")
+	if err := ioutil.WriteFile(filepath.Join(dir, "Lines.cs"), []byte("1\ntwo\n3\n5\nsix\nseven\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lines, err := Lines(filepath.Join(dir, "Lines.cs"), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []csfmt.LineRange{{Start: 2, End: 2}, {Start: 5, End: 6}}; !reflect.DeepEqual(expected, lines) {
+		t.Errorf("Got `%v` but wanted `%v`", lines, expected)
+	}
+	if lines, err := Lines(filepath.Join(dir, "Lines.cs"), true, ""); err != nil || len(lines) != 0 {
+		t.Errorf("Got `%v` but wanted no staged lines", lines)
+	}
+	if lines, err := Lines(filepath.Join(dir, "New.cs"), false, "base"); err != nil || len(lines) != 1 || lines[0].Start != 1 {
+		t.Errorf("Got `%v` but wanted the whole file", lines)
+	}
+	if lines, err := Lines(filepath.Join(dir, "src", "Untracked.cs"), false, ""); err != nil || len(lines) != 1 || lines[0].Start != 1 {
+		t.Errorf("Got `%v` but wanted the whole file", lines)
+	}
+
+	// Staging contents the working tree does not have
+	found, err = Unstaged(dir)
+	check("unstaged", []string{"Lines.cs", "src/Deep.cs"}, found, err)
+	if err := WriteIndex(filepath.Join(dir, "Staged.cs"), []byte("staged\n")); err != nil {
+		t.Fatal(err)
+	}
+	staged, err := ReadIndex(filepath.Join(dir, "Staged.cs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(staged) != "staged\n" {
+		t.Errorf("Got `%s` but wanted `staged`", staged)
+	}
+	found, err = Unstaged(filepath.Join(dir, "src"))
+	check("unstaged after staging", []string{"Lines.cs", "Staged.cs", "src/Deep.cs"}, found, err)
+	if err := WriteIndex(filepath.Join(dir, "src", "Untracked.cs"), []byte("\n")); err == nil {
+		t.Errorf("Got no error staging an untracked file but wanted one")
+	}
+
+	// Staged lines of a file changed again since
+	partly := []struct {
+		staged   string
+		working  string
+		expected []csfmt.LineRange
+	}{
+		{staged: "1\n2\nthree\n4\n", working: "zero\nhalf\n1\n2\nthree\n4\n", expected: []csfmt.LineRange{{Start: 5, End: 5}}},
+		{staged: "1\n2\nthree\nfour\n", working: "zero\n1\n2\nthree\n4\n", expected: []csfmt.LineRange{{Start: 4, End: 5}}},
+		{staged: "1\ntwo\nthree\n4\n", working: "1\nthree\n4\n", expected: []csfmt.LineRange{{Start: 2, End: 2}}},
+		{staged: "1\ntwo\n3\n4\n", working: "1\n3\n4\n", expected: []csfmt.LineRange{}},
+	}
+	path := filepath.Join(dir, "Partly.cs")
+	if err := ioutil.WriteFile(path, []byte("1\n2\n3\n4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "Partly.cs")
+	git("commit", "-q", "-m", "partly", "--", "Partly.cs")
+	for _, test := range partly {
+		if err := ioutil.WriteFile(path, []byte(test.staged), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "Partly.cs")
+		if err := ioutil.WriteFile(path, []byte(test.working), 0644); err != nil {
+			t.Fatal(err)
+		}
+		lines, err := Lines(path, true, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(test.expected, lines) {
+			t.Errorf("Got `%v` but wanted `%v` staged in `%q`", lines, test.expected, test.working)
+		}
+	}
+
+	// Hooks wherever git keeps them
+	hooks, err := HooksDir(filepath.Join(dir, "src"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(dir, ".git", "hooks"); hooks != expected {
+		t.Errorf("Got `%s` but wanted `%s`", hooks, expected)
+	}
+	git("config", "core.hooksPath", ".githooks")
+	hooks, err = HooksDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(dir, ".githooks"); hooks != expected {
+		t.Errorf("Got `%s` but wanted `%s`", hooks, expected)
+	}
+}
@@ -0,0 +1,199 @@
+// Package ignore reads the .csfmtignore files of a project, and optionally
+// its .gitignore files, and works out which paths are left out.
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// FileName is the name of the ignore file looked for in each directory.
+	FileName = ".csfmtignore"
+
+	// GitFileName is the name of git's own ignore file.
+	GitFileName = ".gitignore"
+)
+
+// Defaults are the patterns in effect before any ignore file is read. An
+// ignore file may still bring any of them back with a negated pattern.
+var Defaults = []string{".git/", "bin/", "obj/"}
+
+// Parse reads the patterns of an ignore file.
+func Parse(r io.Reader) ([]Pattern, error) {
+	patterns := []Pattern{}
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if number == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		pattern, ok, err := ParsePattern(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", number, err)
+		}
+		if ok {
+			patterns = append(patterns, pattern)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+// Loader works out which paths are ignored, remembering the ignore files it
+// has already read for each directory, so walking a large tree reads each
+// of them only once.
+type Loader struct {
+	names    []string
+	base     string
+	defaults []Pattern
+
+	mu    sync.Mutex
+	cache map[string]*directory
+}
+
+// directory holds the patterns read from the ignore files of a directory.
+type directory struct {
+	patterns []Pattern
+
+	// root is set for the top of a git repository, which stops the search
+	// for ignore files in parent directories.
+	root bool
+}
+
+// NewLoader creates a loader for paths found from the base directory which
+// reads the ignore files with the given names, later files winning over
+// earlier ones.
+func NewLoader(base string, names ...string) (*Loader, error) {
+	abs, err := filepath.Abs(base)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Loader{
+		names: names,
+		base:  abs,
+		cache: map[string]*directory{},
+	}
+	for _, line := range Defaults {
+		pattern, _, err := ParsePattern(line)
+		if err != nil {
+			return nil, err
+		}
+		l.defaults = append(l.defaults, pattern)
+	}
+	return l, nil
+}
+
+// Ignored reports whether the path, or any directory holding it beneath the
+// base, is left out.
+func (l *Loader) Ignored(path string, isDir bool) (bool, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+
+	// Every directory which may hold ignore files, from the top down
+	dirs := []string{}
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		d, err := l.read(dir)
+		if err != nil {
+			return false, err
+		}
+		dirs = append([]string{dir}, dirs...)
+		if d.root || filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	// The path along with each directory holding it beneath the base, from
+	// the top down
+	paths := []string{abs}
+	if within(l.base, abs) {
+		for dir := filepath.Dir(abs); within(l.base, dir); dir = filepath.Dir(dir) {
+			paths = append([]string{dir}, paths...)
+		}
+	}
+
+	for i, p := range paths {
+		ignored, err := l.matches(dirs, p, isDir || i < len(paths)-1)
+		if err != nil || ignored {
+			return ignored, err
+		}
+	}
+	return false, nil
+}
+
+// matches reports whether the last pattern matching the path ignores it.
+func (l *Loader) matches(dirs []string, path string, isDir bool) (bool, error) {
+	ignored := false
+	for _, pattern := range l.defaults {
+		if pattern.Match(filepath.Base(path), isDir) {
+			ignored = !pattern.Negate
+		}
+	}
+	for _, dir := range dirs {
+		if !within(dir, path) {
+			continue
+		}
+		d, err := l.read(dir)
+		if err != nil {
+			return false, err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return false, err
+		}
+		for _, pattern := range d.patterns {
+			if pattern.Match(filepath.ToSlash(rel), isDir) {
+				ignored = !pattern.Negate
+			}
+		}
+	}
+	return ignored, nil
+}
+
+func (l *Loader) read(dir string) (*directory, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if d, ok := l.cache[dir]; ok {
+		return d, nil
+	}
+
+	d := &directory{}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		d.root = true
+	}
+	for _, name := range l.names {
+		path := filepath.Join(dir, name)
+		r, err := os.Open(path)
+		switch {
+		case os.IsNotExist(err):
+			continue
+		case err != nil:
+			return nil, err
+		}
+		patterns, err := Parse(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		d.patterns = append(d.patterns, patterns...)
+	}
+
+	l.cache[dir] = d
+	return d, nil
+}
+
+// within reports whether the path is found beneath the directory.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
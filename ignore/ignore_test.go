@@ -0,0 +1,79 @@
+package ignore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csfmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		filepath.Join("bin", "repo", ".git", "HEAD"):            "",
+		filepath.Join("bin", "repo", FileName):                  "vendor/\n*.Old.cs\n!Keep.Old.cs\n!obj/\n",
+		filepath.Join("bin", "repo", GitFileName):               "*.local.cs\n",
+		filepath.Join("bin", "repo", "src", FileName):           "/Generated\n!*.Old.cs\n",
+		filepath.Join("bin", "repo", "lib", "vendor", FileName): "!*.cs\n",
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	base := filepath.Join(dir, "bin", "repo")
+
+	tests := []struct {
+		description string
+		given       string
+		dir         bool
+		expected    bool
+	}{
+		{description: "source", given: "Program.cs", expected: false},
+		{description: "base inside an ignored directory name", given: "", dir: true, expected: false},
+		{description: "default build output", given: filepath.Join("src", "bin"), dir: true, expected: true},
+		{description: "default brought back", given: filepath.Join("src", "obj"), dir: true, expected: false},
+		{description: "git directory", given: ".git", dir: true, expected: true},
+		{description: "directory pattern", given: filepath.Join("lib", "vendor"), dir: true, expected: true},
+		{description: "inside an ignored directory", given: filepath.Join("lib", "vendor", "A.cs"), expected: true},
+		{description: "pattern", given: filepath.Join("lib", "A.Old.cs"), expected: true},
+		{description: "negated", given: filepath.Join("lib", "Keep.Old.cs"), expected: false},
+		{description: "closer file wins", given: filepath.Join("src", "A.Old.cs"), expected: false},
+		{description: "anchored to its file", given: filepath.Join("src", "Generated", "A.cs"), expected: true},
+		{description: "anchored elsewhere", given: filepath.Join("lib", "Generated", "A.cs"), expected: false},
+		{description: "gitignore not read", given: "A.local.cs", expected: false},
+	}
+
+	loader, err := NewLoader(base, FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual, err := loader.Ignored(filepath.Join(base, test.given), test.dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != test.expected {
+				t.Errorf("Got `%t` but wanted `%t`", actual, test.expected)
+			}
+		})
+	}
+
+	git, err := NewLoader(base, GitFileName, FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ignored, err := git.Ignored(filepath.Join(base, "A.local.cs"), false); err != nil || !ignored {
+		t.Errorf("Got `%t` but wanted the .gitignore file read", ignored)
+	}
+}
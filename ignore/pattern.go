@@ -0,0 +1,135 @@
+package ignore
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Pattern is a single line of an ignore file.
+type Pattern struct {
+	// Negate brings back paths left out by an earlier pattern.
+	Negate bool
+
+	// Directory only matches directories.
+	Directory bool
+
+	re *regexp.Regexp
+}
+
+// ParsePattern reads a line of an ignore file, reporting false for blank
+// lines and comments.
+func ParsePattern(line string) (Pattern, bool, error) {
+	// Trailing spaces are trimmed unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || line[0] == '#' {
+		return Pattern{}, false, nil
+	}
+
+	p := Pattern{}
+	switch {
+	case line[0] == '!':
+		p.Negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.Directory = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return Pattern{}, false, nil
+	}
+
+	if strings.Contains(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+	} else {
+		line = "**/" + line
+	}
+	re, err := regexp.Compile("^" + translate(line) + "$")
+	if err != nil {
+		return Pattern{}, false, err
+	}
+	p.re = re
+	return p, true, nil
+}
+
+// Match reports whether the path, relative to the directory holding the
+// ignore file and separated by forward slashes, matches the pattern.
+func (p Pattern) Match(path string, isDir bool) bool {
+	if p.Directory && !isDir {
+		return false
+	}
+	return p.re.MatchString(path)
+}
+
+func translate(glob string) string {
+	out := &strings.Builder{}
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/") && (i == 0 || glob[i-1] == '/'):
+			out.WriteString("(?:.*/)?")
+			i += 2
+		case glob[i:] == "**" && i > 0 && glob[i-1] == '/':
+			out.WriteString(".*")
+			i++
+		case c == '*':
+			out.WriteString("[^/]*")
+		case c == '?':
+			out.WriteString("[^/]")
+		case c == '[':
+			class, n, ok := characterClass(glob[i:])
+			if !ok {
+				out.WriteString(`\[`)
+				continue
+			}
+			out.WriteString(class)
+			i += n - 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			out.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			out.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return out.String()
+}
+
+// characterClass translates the set of characters the glob starts with,
+// returning how much of the glob it takes up. A set which is never closed
+// is not a set at all.
+func characterClass(glob string) (string, int, bool) {
+	const punctuation = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+	out := &strings.Builder{}
+	out.WriteString("[")
+	i := 1
+	if i < len(glob) && (glob[i] == '!' || glob[i] == '^') {
+		out.WriteString("^/")
+		i++
+	}
+	for first := true; i < len(glob); i, first = i+1, false {
+		c := glob[i]
+		switch {
+		case c == ']' && !first:
+			out.WriteString("]")
+			return out.String(), i + 1, true
+		case c == '\\' && i+1 < len(glob):
+			i++
+			c = glob[i]
+			if strings.IndexByte(punctuation, c) >= 0 {
+				out.WriteByte('\\')
+			}
+			out.WriteByte(c)
+		case c == '[' || c == ']' || c == '\\':
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return "", 0, false
+}
@@ -0,0 +1,71 @@
+package ignore
+
+import (
+	"testing"
+)
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		path     string
+		dir      bool
+		expected bool
+	}{
+		{pattern: "*.g.cs", path: "src/deep/Views.g.cs", expected: true},
+		{pattern: "*.g.cs", path: "Views.cs", expected: false},
+		{pattern: "bin/", path: "src/bin", dir: true, expected: true},
+		{pattern: "bin/", path: "src/bin", expected: false},
+		{pattern: "/vendor", path: "vendor", dir: true, expected: true},
+		{pattern: "/vendor", path: "src/vendor", dir: true, expected: false},
+		{pattern: "src/*.cs", path: "src/A.cs", expected: true},
+		{pattern: "src/*.cs", path: "src/deep/A.cs", expected: false},
+		{pattern: "src/*.cs", path: "lib/src/A.cs", expected: false},
+		{pattern: "**/Migrations", path: "src/Data/Migrations", dir: true, expected: true},
+		{pattern: "src/**/Old.cs", path: "src/Old.cs", expected: true},
+		{pattern: "src/**/Old.cs", path: "src/a/b/Old.cs", expected: true},
+		{pattern: "third_party/**", path: "third_party/lib/A.cs", expected: true},
+		{pattern: "third_party/**", path: "third_party", dir: true, expected: false},
+		{pattern: "File?.cs", path: "File1.cs", expected: true},
+		{pattern: "File?.cs", path: "File10.cs", expected: false},
+		{pattern: "File[0-9].cs", path: "File7.cs", expected: true},
+		{pattern: "File[!0-9].cs", path: "File7.cs", expected: false},
+		{pattern: "File[!0-9].cs", path: "FileA.cs", expected: true},
+		{pattern: "[.cs", path: "[.cs", expected: true},
+		{pattern: `\#Notes.cs`, path: "#Notes.cs", expected: true},
+		{pattern: `\!Important.cs`, path: "!Important.cs", expected: true},
+		{pattern: `Space\ `, path: "Space ", expected: true},
+		{pattern: "Trailing.cs   ", path: "Trailing.cs", expected: true},
+		{pattern: "Ünïcode.cs", path: "Ünïcode.cs", expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.path, func(t *testing.T) {
+			pattern, ok, err := ParsePattern(test.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Fatalf("Got no pattern for `%s`", test.pattern)
+			}
+			if actual := pattern.Match(test.path, test.dir); actual != test.expected {
+				t.Errorf("Got `%t` but wanted `%t`", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestParsePattern(t *testing.T) {
+	for _, line := range []string{"", "   ", "# comment", "/"} {
+		if _, ok, err := ParsePattern(line); ok || err != nil {
+			t.Errorf("Got a pattern for `%s` but wanted none", line)
+		}
+	}
+
+	pattern, _, err := ParsePattern("!build/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pattern.Negate || !pattern.Directory {
+		t.Errorf("Got `%+v` but wanted a negated directory pattern", pattern)
+	}
+}
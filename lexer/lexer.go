@@ -0,0 +1,449 @@
+// Package lexer turns C# source code into a lossless stream of tokens.
+package lexer
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+type lexer struct {
+	source []byte
+	pos    int
+	line   int
+	column int
+}
+
+// Lex splits the source into tokens. Every byte of the source belongs to
+// exactly one token; malformed input such as an unterminated string is
+// lexed up to the point where it can no longer continue rather than
+// reported as an error.
+func Lex(source []byte) []Token {
+	l := &lexer{
+		source: source,
+		line:   1,
+		column: 1,
+	}
+
+	tokens := []Token{}
+	lineStart := true
+	for l.pos < len(l.source) {
+		start := l.pos
+		kind := l.next(lineStart)
+		token := Token{
+			Kind:   kind,
+			Text:   l.source[start:l.pos],
+			Offset: start,
+			Line:   l.line,
+			Column: l.column,
+		}
+		tokens = append(tokens, token)
+		l.advance(token.Text)
+
+		if kind == Newline {
+			lineStart = true
+		} else if kind != Whitespace && kind != BlockComment && kind != DocComment {
+			lineStart = false
+		}
+	}
+
+	return tokens
+}
+
+// advance moves the line and column past the given text.
+func (l *lexer) advance(text []byte) {
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRune(text[i:])
+		i += size
+		if r == '\r' && i < len(text) && text[i] == '\n' {
+			continue
+		}
+		if r == '\n' || r == '\r' {
+			l.line++
+			l.column = 1
+		} else {
+			l.column++
+		}
+	}
+}
+
+func (l *lexer) peek(offset int) byte {
+	if l.pos+offset < len(l.source) {
+		return l.source[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *lexer) rune() (rune, int) {
+	return utf8.DecodeRune(l.source[l.pos:])
+}
+
+func (l *lexer) hasPrefix(prefix string) bool {
+	return bytes.HasPrefix(l.source[l.pos:], []byte(prefix))
+}
+
+// next consumes a single token and returns its kind.
+func (l *lexer) next(lineStart bool) Kind {
+	c := l.peek(0)
+	switch {
+	case c == '\r' || c == '\n':
+		if l.hasPrefix("\r\n") {
+			l.pos += 2
+		} else {
+			l.pos++
+		}
+		return Newline
+	case l.hasPrefix("///") && !l.hasPrefix("////"):
+		l.toEndOfLine()
+		return DocComment
+	case l.hasPrefix("//"):
+		l.toEndOfLine()
+		return Comment
+	case l.hasPrefix("/*"):
+		doc := l.hasPrefix("/**") && !l.hasPrefix("/**/") && !l.hasPrefix("/***")
+		l.pos += 2
+		if end := bytes.Index(l.source[l.pos:], []byte("*/")); end >= 0 {
+			l.pos += end + 2
+		} else {
+			l.pos = len(l.source)
+		}
+		if doc {
+			return DocComment
+		}
+		return BlockComment
+	case c == '#' && lineStart:
+		l.toEndOfLine()
+		return Preprocessor
+	case c == '"' || c == '$' || (c == '@' && (l.peek(1) == '"' || l.peek(1) == '$')):
+		if kind, ok := l.stringLiteral(); ok {
+			return kind
+		}
+	case c == '\'':
+		l.charLiteral()
+		return Char
+	case isDigit(c) || (c == '.' && isDigit(l.peek(1))):
+		l.number()
+		return Number
+	}
+
+	r, size := l.rune()
+	switch {
+	case isWhitespace(r):
+		for l.pos < len(l.source) {
+			r, size = l.rune()
+			if !isWhitespace(r) {
+				break
+			}
+			l.pos += size
+		}
+		return Whitespace
+	case r == '@' || l.hasPrefix(`\u`) || l.hasPrefix(`\U`) || isIdentifierStart(r):
+		start := l.pos
+		if r == '@' {
+			l.pos += size
+		}
+		l.identifier()
+		if l.pos == start+size && r == '@' {
+			return Invalid
+		}
+		if r != '@' && IsKeyword(string(l.source[start:l.pos])) {
+			return Keyword
+		}
+		return Identifier
+	case r == utf8.RuneError && size <= 1:
+		l.pos++
+		return Invalid
+	}
+
+	for _, op := range operators {
+		if l.hasPrefix(op) {
+			l.pos += len(op)
+			return Punctuation
+		}
+	}
+	if bytes.IndexByte([]byte("{}[]().,:;+-*/%&|^!~=<>?"), c) >= 0 && c != 0 {
+		l.pos++
+		return Punctuation
+	}
+
+	l.pos += size
+	return Invalid
+}
+
+// toEndOfLine consumes everything up to the end of the line.
+func (l *lexer) toEndOfLine() {
+	for l.pos < len(l.source) && l.source[l.pos] != '\n' && l.source[l.pos] != '\r' {
+		l.pos++
+	}
+}
+
+// identifier consumes the rest of an identifier, unicode escapes included.
+func (l *lexer) identifier() {
+	for l.pos < len(l.source) {
+		if l.hasPrefix(`\u`) {
+			l.pos += 2
+			continue
+		}
+		if l.hasPrefix(`\U`) {
+			l.pos += 2
+			continue
+		}
+		r, size := l.rune()
+		if !isIdentifierPart(r) {
+			return
+		}
+		l.pos += size
+	}
+}
+
+// number consumes a numeric literal along with any type suffix.
+func (l *lexer) number() {
+	if l.peek(0) == '0' && (l.peek(1) == 'x' || l.peek(1) == 'X' || l.peek(1) == 'b' || l.peek(1) == 'B') {
+		l.pos += 2
+		for isHexDigit(l.peek(0)) || l.peek(0) == '_' {
+			l.pos++
+		}
+	} else {
+		l.digits()
+		if l.peek(0) == '.' && isDigit(l.peek(1)) {
+			l.pos++
+			l.digits()
+		}
+		if c := l.peek(0); c == 'e' || c == 'E' {
+			offset := 1
+			if s := l.peek(1); s == '+' || s == '-' {
+				offset = 2
+			}
+			if isDigit(l.peek(offset)) {
+				l.pos += offset
+				l.digits()
+			}
+		}
+	}
+
+	// Type suffixes such as UL, f, d and m
+	for {
+		switch l.peek(0) {
+		case 'u', 'U', 'l', 'L', 'f', 'F', 'd', 'D', 'm', 'M':
+			l.pos++
+			continue
+		}
+		return
+	}
+}
+
+// digits consumes decimal digits and the separators between them.
+func (l *lexer) digits() {
+	for isDigit(l.peek(0)) || l.peek(0) == '_' {
+		l.pos++
+	}
+}
+
+// charLiteral consumes a character literal up to and including the closing
+// quote, stopping at the end of the line when there is none.
+func (l *lexer) charLiteral() {
+	l.pos++
+	for l.pos < len(l.source) {
+		switch l.source[l.pos] {
+		case '\\':
+			l.pos++
+			if l.pos < len(l.source) && l.source[l.pos] != '\n' && l.source[l.pos] != '\r' {
+				_, size := l.rune()
+				l.pos += size
+			}
+			continue
+		case '\'':
+			l.pos++
+			return
+		case '\n', '\r':
+			return
+		}
+		l.pos++
+	}
+}
+
+// stringLiteral consumes any form of string literal beginning at the
+// current position. It reports false without consuming anything when the
+// prefix does not actually start a string, such as a lone '$'.
+func (l *lexer) stringLiteral() (Kind, bool) {
+	start := l.pos
+	dollars := 0
+	verbatim := false
+	for {
+		switch l.peek(0) {
+		case '$':
+			dollars++
+			l.pos++
+			continue
+		case '@':
+			if verbatim {
+				break
+			}
+			verbatim = true
+			l.pos++
+			continue
+		}
+		break
+	}
+
+	if l.peek(0) != '"' || (verbatim && dollars > 1) {
+		l.pos = start
+		return Invalid, false
+	}
+
+	kind := String
+	quotes := 0
+	for l.peek(quotes) == '"' {
+		quotes++
+	}
+
+	switch {
+	case !verbatim && quotes >= 3:
+		l.pos += quotes
+		l.rawString(quotes, dollars)
+		kind = RawString
+	case verbatim:
+		l.pos++
+		l.quotedString(true, dollars)
+		kind = VerbatimString
+	default:
+		l.pos++
+		l.quotedString(false, dollars)
+	}
+	if dollars > 0 {
+		kind = InterpolatedString
+	}
+
+	// UTF-8 string literals
+	if l.hasPrefix("u8") || l.hasPrefix("U8") {
+		l.pos += 2
+	}
+	return kind, true
+}
+
+// quotedString consumes the body of a regular or verbatim string up to and
+// including the closing quote.
+func (l *lexer) quotedString(verbatim bool, dollars int) {
+	for l.pos < len(l.source) {
+		c := l.source[l.pos]
+		switch {
+		case c == '"':
+			if verbatim && l.peek(1) == '"' {
+				l.pos += 2
+				continue
+			}
+			l.pos++
+			return
+		case c == '\\' && !verbatim:
+			l.pos++
+			if l.pos < len(l.source) && l.source[l.pos] != '\n' && l.source[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		case (c == '\n' || c == '\r') && !verbatim:
+			return
+		case c == '{' && dollars > 0:
+			if l.peek(1) == '{' {
+				l.pos += 2
+				continue
+			}
+			l.pos++
+			l.interpolation(1)
+			continue
+		}
+		l.pos++
+	}
+}
+
+// rawString consumes the body of a raw string literal opened by the given
+// number of quotes.
+func (l *lexer) rawString(quotes, dollars int) {
+	for l.pos < len(l.source) {
+		c := l.source[l.pos]
+		switch {
+		case c == '"':
+			run := 0
+			for l.peek(run) == '"' {
+				run++
+			}
+			l.pos += run
+			if run >= quotes {
+				return
+			}
+			continue
+		case c == '{' && dollars > 0:
+			run := 0
+			for l.peek(run) == '{' {
+				run++
+			}
+			l.pos += run
+			if run >= dollars {
+				l.interpolation(dollars)
+			}
+			continue
+		}
+		l.pos++
+	}
+}
+
+// interpolation consumes an interpolation hole up to and including the
+// closing braces, skipping over any nested literals or comments.
+func (l *lexer) interpolation(braces int) {
+	depth := 0
+	for l.pos < len(l.source) {
+		c := l.source[l.pos]
+		switch {
+		case c == '"' || c == '$' || c == '@':
+			if _, ok := l.stringLiteral(); ok {
+				continue
+			}
+		case c == '\'':
+			l.charLiteral()
+			continue
+		case l.hasPrefix("/*"):
+			if end := bytes.Index(l.source[l.pos+2:], []byte("*/")); end >= 0 {
+				l.pos += end + 4
+			} else {
+				l.pos = len(l.source)
+			}
+			continue
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth == 0 {
+				run := 0
+				for run < braces && l.peek(run) == '}' {
+					run++
+				}
+				l.pos += run
+				return
+			}
+			depth--
+		}
+		l.pos++
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isWhitespace(r rune) bool {
+	if r == '\n' || r == '\r' {
+		return false
+	}
+	return r == ' ' || r == '\t' || r == '\v' || r == '\f' || r == '\uFEFF' || unicode.Is(unicode.Zs, r)
+}
+
+func isIdentifierStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r)
+}
+
+func isIdentifierPart(r rune) bool {
+	return isIdentifierStart(r) || unicode.IsDigit(r) ||
+		unicode.In(r, unicode.Mn, unicode.Mc, unicode.Pc, unicode.Cf)
+}
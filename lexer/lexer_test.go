@@ -0,0 +1,120 @@
+package lexer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func describe(tokens []Token) string {
+	parts := []string{}
+	for _, token := range tokens {
+		parts = append(parts, fmt.Sprintf("%s(%s)", token.Kind, token.Text))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		description string
+		given       string
+		expected    string
+	}{
+		{
+			description: "identifiers and keywords",
+			given:       "public var @class",
+			expected:    "Keyword(public) Whitespace( ) Identifier(var) Whitespace( ) Identifier(@class)",
+		},
+		{
+			description: "numeric literals",
+			given:       "0x1F 1_000UL 3.14e-2f .5m",
+			expected:    "Number(0x1F) Whitespace( ) Number(1_000UL) Whitespace( ) Number(3.14e-2f) Whitespace( ) Number(.5m)",
+		},
+		{
+			description: "two strings on one line",
+			given:       `a("x, y", "z ;")`,
+			expected:    `Identifier(a) Punctuation(() String("x, y") Punctuation(,) Whitespace( ) String("z ;") Punctuation())`,
+		},
+		{
+			description: "escaped quotes",
+			given:       `"a\"b" @"c""d"`,
+			expected:    `String("a\"b") Whitespace( ) VerbatimString(@"c""d")`,
+		},
+		{
+			description: "interpolated string with nested string",
+			given:       `$"{a["k"]}, {{b}}" + c`,
+			expected:    `InterpolatedString($"{a["k"]}, {{b}}") Whitespace( ) Punctuation(+) Whitespace( ) Identifier(c)`,
+		},
+		{
+			description: "raw string literal",
+			given:       "\"\"\"\n  say \"\"hi\"\"\n  \"\"\";",
+			expected:    "RawString(\"\"\"\n  say \"\"hi\"\"\n  \"\"\") Punctuation(;)",
+		},
+		{
+			description: "interpolated raw string literal",
+			given:       `$$"""{{a}} {b}"""`,
+			expected:    `InterpolatedString($$"""{{a}} {b}""")`,
+		},
+		{
+			description: "character literals",
+			given:       `';' ',' '\''`,
+			expected:    `Char(';') Whitespace( ) Char(',') Whitespace( ) Char('\'')`,
+		},
+		{
+			description: "block comment opening mid line",
+			given:       "a; /* b;\n c */ d",
+			expected:    "Identifier(a) Punctuation(;) Whitespace( ) BlockComment(/* b;\n c */) Whitespace( ) Identifier(d)",
+		},
+		{
+			description: "single line and documentation comments",
+			given:       "/// <summary>\n// x\n//// y",
+			expected:    "DocComment(/// <summary>) Newline(\n) Comment(// x) Newline(\n) Comment(//// y)",
+		},
+		{
+			description: "preprocessor directives only at the start of a line",
+			given:       "  #region A\r\nx # y",
+			expected:    "Whitespace(  ) Preprocessor(#region A) Newline(\r\n) Identifier(x) Whitespace( ) Invalid(#) Whitespace( ) Identifier(y)",
+		},
+		{
+			description: "operators use the maximal munch",
+			given:       "a??=b>>=c=>d++",
+			expected:    "Identifier(a) Punctuation(??=) Identifier(b) Punctuation(>>=) Identifier(c) Punctuation(=>) Identifier(d) Punctuation(++)",
+		},
+		{
+			description: "unterminated string stops at the end of the line",
+			given:       "\"abc\nd",
+			expected:    "String(\"abc) Newline(\n) Identifier(d)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual := describe(Lex([]byte(test.given)))
+			if actual != test.expected {
+				t.Errorf("Got `%s` but wanted `%s`", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestLexIsLossless(t *testing.T) {
+	given := []byte("using System;\r\n\r\nclass A {\n\tstring s = $@\"{x}\n\"; // end\n\t/** doc */ char c = '\\u0041';\n}\n")
+
+	tokens := Lex(given)
+	actual := []byte{}
+	for _, token := range tokens {
+		actual = append(actual, token.Text...)
+	}
+	if !bytes.Equal(given, actual) {
+		t.Errorf("Got `%s` but wanted `%s`", string(actual), string(given))
+	}
+}
+
+func TestLexPositions(t *testing.T) {
+	tokens := Lex([]byte("a\r\n  /* é\n */ b"))
+	last := tokens[len(tokens)-1]
+	if last.Line != 3 || last.Column != 5 || last.Offset != 15 {
+		t.Errorf("Got %d:%d@%d but wanted 3:5@15", last.Line, last.Column, last.Offset)
+	}
+}
@@ -0,0 +1,133 @@
+package lexer
+
+// Kind identifies the lexical class of a token.
+type Kind int
+
+const (
+	// Invalid is any character which cannot begin a C# token.
+	Invalid Kind = iota
+
+	// Trivia
+	Whitespace
+	Newline
+	Comment
+	BlockComment
+	DocComment
+	Preprocessor
+
+	// Code
+	Identifier
+	Keyword
+	Number
+	String
+	VerbatimString
+	InterpolatedString
+	RawString
+	Char
+	Punctuation
+)
+
+// Token is a slice of the source along with its classification and position.
+// Concatenating the Text of every token returned by Lex reproduces the
+// original source exactly.
+type Token struct {
+	Kind   Kind
+	Text   []byte
+	Offset int // Byte offset of the first byte
+	Line   int // 1-based line number
+	Column int // 1-based column, counted in runes
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return "Kind(?)"
+}
+
+// IsTrivia reports whether tokens of this kind carry no meaning to the
+// compiler. Preprocessor directives are not trivia since they change what
+// gets compiled.
+func (k Kind) IsTrivia() bool {
+	switch k {
+	case Whitespace, Newline, Comment, BlockComment, DocComment:
+		return true
+	}
+	return false
+}
+
+// IsComment reports whether the token kind is any form of comment.
+func (k Kind) IsComment() bool {
+	return k == Comment || k == BlockComment || k == DocComment
+}
+
+// IsLiteral reports whether the token kind is a string or character literal.
+func (k Kind) IsLiteral() bool {
+	switch k {
+	case String, VerbatimString, InterpolatedString, RawString, Char:
+		return true
+	}
+	return false
+}
+
+// End returns the byte offset just past the token.
+func (t Token) End() int {
+	return t.Offset + len(t.Text)
+}
+
+// Is reports whether the token is of the given kind with the given text.
+func (t Token) Is(kind Kind, text string) bool {
+	return t.Kind == kind && string(t.Text) == text
+}
+
+// IsKeyword reports whether the word is a reserved C# keyword. Contextual
+// keywords such as var or async are lexed as identifiers.
+func IsKeyword(word string) bool {
+	return keywords[word]
+}
+
+var kindNames = map[Kind]string{
+	Invalid:            "Invalid",
+	Whitespace:         "Whitespace",
+	Newline:            "Newline",
+	Comment:            "Comment",
+	BlockComment:       "BlockComment",
+	DocComment:         "DocComment",
+	Preprocessor:       "Preprocessor",
+	Identifier:         "Identifier",
+	Keyword:            "Keyword",
+	Number:             "Number",
+	String:             "String",
+	VerbatimString:     "VerbatimString",
+	InterpolatedString: "InterpolatedString",
+	RawString:          "RawString",
+	Char:               "Char",
+	Punctuation:        "Punctuation",
+}
+
+var keywords = map[string]bool{
+	"abstract": true, "as": true, "base": true, "bool": true, "break": true,
+	"byte": true, "case": true, "catch": true, "char": true, "checked": true,
+	"class": true, "const": true, "continue": true, "decimal": true, "default": true,
+	"delegate": true, "do": true, "double": true, "else": true, "enum": true,
+	"event": true, "explicit": true, "extern": true, "false": true, "finally": true,
+	"fixed": true, "float": true, "for": true, "foreach": true, "goto": true,
+	"if": true, "implicit": true, "in": true, "int": true, "interface": true,
+	"internal": true, "is": true, "lock": true, "long": true, "namespace": true,
+	"new": true, "null": true, "object": true, "operator": true, "out": true,
+	"override": true, "params": true, "private": true, "protected": true, "public": true,
+	"readonly": true, "ref": true, "return": true, "sbyte": true, "sealed": true,
+	"short": true, "sizeof": true, "stackalloc": true, "static": true, "string": true,
+	"struct": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "uint": true, "ulong": true, "unchecked": true,
+	"unsafe": true, "ushort": true, "using": true, "virtual": true, "void": true,
+	"volatile": true, "while": true,
+}
+
+// Operators ordered longest first so the lexer can take the maximal munch.
+var operators = []string{
+	">>>=",
+	">>>", "<<=", ">>=", "??=",
+	"<<", ">>", "=>", "==", "!=", "<=", ">=", "&&", "||", "++", "--",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "->", "::", "??", "..",
+}
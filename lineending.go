@@ -0,0 +1,103 @@
+package csfmt
+
+import (
+	"bytes"
+	"runtime"
+	"sort"
+)
+
+// LineEnding is the sequence of bytes ending each line of a file.
+type LineEnding string
+
+const (
+	LF   LineEnding = "\n"
+	CRLF LineEnding = "\r\n"
+)
+
+// NativeLineEnding is the line ending of the operating system we run on.
+func NativeLineEnding() LineEnding {
+	if runtime.GOOS == "windows" {
+		return CRLF
+	}
+	return LF
+}
+
+// DetectLineEnding returns the line ending used most by the source and
+// whether the source uses both.
+func DetectLineEnding(source []byte) (LineEnding, bool) {
+	lf, crlf := 0, 0
+	first := LF
+	for i, c := range source {
+		if c != '\n' {
+			continue
+		}
+		if i == 0 || source[i-1] != '\r' {
+			lf++
+			continue
+		}
+		if lf+crlf == 0 {
+			first = CRLF
+		}
+		crlf++
+	}
+
+	mixed := lf > 0 && crlf > 0
+	switch {
+	case crlf > lf:
+		return CRLF, mixed
+	case lf > crlf:
+		return LF, mixed
+	}
+	return first, mixed
+}
+
+// allCRLF reports whether every line of the source ends with CRLF.
+func allCRLF(source []byte) bool {
+	ending, mixed := DetectLineEnding(source)
+	return ending == CRLF && !mixed
+}
+
+// applyCRLF applies the rule to a source ending every line with CRLF, as
+// though the Apply function knew about CRLF line endings.
+func (r *Rule) applyCRLF(source []byte) []byte {
+	lf := bytes.Replace(source, []byte(CRLF), []byte(LF), -1)
+	return bytes.Replace(r.Apply(lf), []byte(LF), []byte(CRLF), -1)
+}
+
+// applyEdits works out the edits the Apply function of the rule makes to
+// the source, as though it knew about CRLF line endings.
+func (r *Rule) applyEdits(source []byte) []Edit {
+	if bytes.IndexByte(source, '\r') < 0 {
+		return Changes(source, r.Apply(source))
+	}
+
+	// Remember where each line of the LF source starts and how many CRs
+	// were dropped before it
+	lf := make([]byte, 0, len(source))
+	starts, dropped := []int{0}, []int{0}
+	for i := 0; i < len(source); i++ {
+		if source[i] == '\r' && i+1 < len(source) && source[i+1] == '\n' {
+			continue
+		}
+		lf = append(lf, source[i])
+		if source[i] == '\n' {
+			starts = append(starts, len(lf))
+			dropped = append(dropped, i+1-len(lf))
+		}
+	}
+	original := func(offset int) int {
+		line := sort.SearchInts(starts, offset+1) - 1
+		return offset + dropped[line]
+	}
+
+	ending, _ := DetectLineEnding(source)
+	edits := Changes(lf, r.Apply(lf))
+	for i := range edits {
+		edits[i].Start = original(edits[i].Start)
+		edits[i].End = original(edits[i].End)
+		if ending == CRLF {
+			edits[i].Text = bytes.Replace(edits[i].Text, []byte(LF), []byte(CRLF), -1)
+		}
+	}
+	return edits
+}
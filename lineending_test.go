@@ -0,0 +1,105 @@
+package csfmt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDetectLineEnding(t *testing.T) {
+	tests := []struct {
+		description string
+		given       string
+		expected    LineEnding
+		mixed       bool
+	}{
+		{description: "no line breaks", given: "a;", expected: LF},
+		{description: "lf", given: "a;\nb;\n", expected: LF},
+		{description: "crlf", given: "a;\r\nb;\r\n", expected: CRLF},
+		{description: "mostly crlf", given: "a;\r\nb;\nc;\r\n", expected: CRLF, mixed: true},
+		{description: "tied", given: "a;\r\nb;\n", expected: CRLF, mixed: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual, mixed := DetectLineEnding([]byte(test.given))
+			if actual != test.expected || mixed != test.mixed {
+				t.Errorf("Got `%q` (mixed %t) but wanted `%q` (mixed %t)", actual, mixed, test.expected, test.mixed)
+			}
+		})
+	}
+}
+
+func TestFormatKeepsLineEndings(t *testing.T) {
+	// A rule written for LF only which trims trailing spaces and joins
+	// runs of blank lines
+	rule := &Rule{
+		ID: "A",
+		Apply: func(source []byte) []byte {
+			source = bytes.Replace(source, []byte(" \n"), []byte("\n"), -1)
+			return bytes.Replace(source, []byte("\n\n\n"), []byte("\n\n"), -1)
+		},
+	}
+
+	tests := []struct {
+		description string
+		given       string
+		expected    string
+	}{
+		{description: "lf", given: "a; \n\n\nb;\n", expected: "a;\n\nb;\n"},
+		{description: "crlf", given: "a; \r\n\r\n\r\nb;\r\n", expected: "a;\r\n\r\nb;\r\n"},
+		{description: "mixed lines left alone", given: "a; \r\nb;\nc;\r\n", expected: "a;\r\nb;\nc;\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual, err := rule.Format("", []byte(test.given))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(actual) != test.expected {
+				t.Errorf("Got `%q` but wanted `%q`", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestApplyEditsMovesOffsets(t *testing.T) {
+	rule := &Rule{
+		ID: "A",
+		Apply: func(source []byte) []byte {
+			source = bytes.Replace(source, []byte(" \n"), []byte("\n"), -1)
+			return bytes.Replace(source, []byte("b;"), []byte("d;"), -1)
+		},
+	}
+
+	tests := []struct {
+		description string
+		given       string
+		expected    string
+	}{
+		{description: "crlf", given: "a; \r\nb;\r\n", expected: "a;\r\nd;\r\n"},
+		{description: "mixed", given: "a; \r\nb;\nc; \r\nb;\r\n", expected: "a;\r\nd;\nc;\r\nd;\r\n"},
+		{description: "lone carriage return", given: "a;\rb; \r\nb;", expected: "a;\rd;\r\nd;"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			source := []byte(test.given)
+			edits := rule.applyEdits(source)
+			for _, edit := range edits {
+				for _, offset := range []int{edit.Start, edit.End} {
+					if offset > 0 && offset < len(source) && source[offset-1] == '\r' && source[offset] == '\n' {
+						t.Errorf("Got an edit %d:%d splitting a line ending", edit.Start, edit.End)
+					}
+				}
+			}
+			actual, err := ApplyEdits(source, edits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(actual) != test.expected {
+				t.Errorf("Got `%q` but wanted `%q`", actual, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,380 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/lexer"
+)
+
+// Server answers an editor about the documents it has open.
+type Server struct {
+	// Format formats the source of the file found at path, only changing
+	// the ranges when there are any.
+	Format func(path string, source []byte, ranges []csfmt.Range) ([]byte, error)
+
+	// Check finds every violation of the rules within the source of the
+	// file found at path.
+	Check func(path string, source []byte) ([]csfmt.Diagnostic, error)
+
+	conn        *conn
+	documents   map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+// document is a file as the editor has it, saved or not.
+type document struct {
+	text    []byte
+	version int
+}
+
+// Serve answers the messages read from r, replying on w, until the editor
+// says to exit.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.conn = &conn{r: bufio.NewReader(r), w: w}
+	s.documents = map[string]*document{}
+	for {
+		m, err := s.conn.read()
+		if invalid, ok := err.(*ResponseError); ok {
+			if err := s.conn.write(errorResponse{JSONRPC: "2.0", Error: invalid}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err == io.EOF {
+			if s.shutdown {
+				return nil
+			}
+			return errors.New("the editor went away without shutting down")
+		}
+		if err != nil {
+			return err
+		}
+
+		if m.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("told to exit before shutting down")
+			}
+			return nil
+		}
+		if m.Method == "" {
+			// A response to a request the server never makes
+			continue
+		}
+
+		result, err := s.handle(m)
+		if len(m.ID) == 0 {
+			if failed, ok := err.(*ResponseError); err != nil && (!ok || failed.Code != MethodNotFound) {
+				log.Printf("%s: %s\n", m.Method, err)
+			}
+			continue
+		}
+		if err != nil {
+			failed, ok := err.(*ResponseError)
+			if !ok {
+				failed = &ResponseError{Code: RequestFailed, Message: err.Error()}
+			}
+			err = s.conn.write(errorResponse{JSONRPC: "2.0", ID: m.ID, Error: failed})
+		} else {
+			err = s.conn.write(response{JSONRPC: "2.0", ID: m.ID, Result: result})
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handle answers a single request or notification.
+func (s *Server) handle(m *message) (interface{}, error) {
+	switch {
+	case m.Method == "initialize":
+		s.initialized = true
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:                TextDocumentSyncOptions{OpenClose: true, Change: SyncIncremental},
+				DocumentFormattingProvider:      true,
+				DocumentRangeFormattingProvider: true,
+				DocumentOnTypeFormattingProvider: DocumentOnTypeFormattingOptions{
+					FirstTriggerCharacter: ";",
+					MoreTriggerCharacter:  []string{"}"},
+				},
+				CodeActionProvider: CodeActionOptions{CodeActionKinds: []string{QuickFix}},
+			},
+			ServerInfo: ServerInfo{Name: "csfmt"},
+		}, nil
+	case !s.initialized:
+		return nil, &ResponseError{Code: ServerNotInitialized, Message: "not initialized yet"}
+	case s.shutdown:
+		return nil, &ResponseError{Code: InvalidRequest, Message: "shutting down"}
+	}
+
+	switch m.Method {
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := decode(m.Params, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.open(params)
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := decode(m.Params, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.change(params)
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := decode(m.Params, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.close(params)
+	case "textDocument/formatting":
+		var params DocumentFormattingParams
+		if err := decode(m.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.edits(params.TextDocument.URI, nil)
+	case "textDocument/rangeFormatting":
+		var params DocumentRangeFormattingParams
+		if err := decode(m.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.edits(params.TextDocument.URI, []csfmt.LineRange{lines(params.Range)})
+	case "textDocument/onTypeFormatting":
+		var params DocumentOnTypeFormattingParams
+		if err := decode(m.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.onType(params)
+	case "textDocument/codeAction":
+		var params CodeActionParams
+		if err := decode(m.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.codeActions(params)
+	}
+	return nil, &ResponseError{Code: MethodNotFound, Message: "unknown method " + m.Method}
+}
+
+// decode reads the parameters of a message.
+func decode(raw json.RawMessage, params interface{}) error {
+	if err := json.Unmarshal(raw, params); err != nil {
+		return &ResponseError{Code: InvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// open starts keeping the document in sync.
+func (s *Server) open(params DidOpenTextDocumentParams) error {
+	item := params.TextDocument
+	s.documents[item.URI] = &document{text: []byte(item.Text), version: item.Version}
+	return s.publish(item.URI)
+}
+
+// change makes the changes to the document in the order they were made.
+func (s *Server) change(params DidChangeTextDocumentParams) error {
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return err
+	}
+	for _, change := range params.ContentChanges {
+		d.text = applyChange(d.text, change)
+	}
+	d.version = params.TextDocument.Version
+	return s.publish(params.TextDocument.URI)
+}
+
+// close stops keeping the document and clears its diagnostics.
+func (s *Server) close(params DidCloseTextDocumentParams) error {
+	delete(s.documents, params.TextDocument.URI)
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         params.TextDocument.URI,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+// document returns the open document named by the URI.
+func (s *Server) document(uri string) (*document, error) {
+	d, ok := s.documents[uri]
+	if !ok {
+		return nil, &ResponseError{Code: InvalidParams, Message: "document is not open: " + uri}
+	}
+	return d, nil
+}
+
+// publish sends every diagnostic of the document.
+func (s *Server) publish(uri string) error {
+	d, err := s.document(uri)
+	if err != nil {
+		return err
+	}
+	found, err := s.Check(uriPath(uri), d.text)
+	if err != nil {
+		return err
+	}
+	diagnostics := []Diagnostic{}
+	for _, f := range found {
+		diagnostics = append(diagnostics, diagnostic(d.text, f))
+	}
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Version:     d.version,
+		Diagnostics: diagnostics,
+	})
+}
+
+// notify sends a notification to the editor.
+func (s *Server) notify(method string, params interface{}) error {
+	return s.conn.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+var severities = map[csfmt.Severity]int{
+	csfmt.SeverityError:   SeverityError,
+	csfmt.SeverityWarning: SeverityWarning,
+	csfmt.SeverityInfo:    SeverityInformation,
+}
+
+// diagnostic describes a violation found in the text to the editor,
+// covering the text its fix would replace.
+func diagnostic(text []byte, d csfmt.Diagnostic) Diagnostic {
+	code := d.Rule.ID
+	if d.Rule.Code != "" {
+		code = d.Rule.Code
+	}
+	at := Range{
+		Start: Position{Line: d.Line - 1, Character: d.Column - 1},
+		End:   Position{Line: d.Line - 1, Character: d.Column - 1},
+	}
+	if d.Fix != nil {
+		at = Range{Start: position(text, d.Fix.Start), End: position(text, d.Fix.End)}
+	}
+	return Diagnostic{
+		Range:    at,
+		Severity: severities[d.Severity],
+		Code:     code,
+		Source:   "csfmt",
+		Message:  d.Message,
+	}
+}
+
+// edits formats the document, only changing the lines when there are any.
+func (s *Server) edits(uri string, lines []csfmt.LineRange) ([]TextEdit, error) {
+	d, err := s.document(uri)
+	if err != nil {
+		return nil, err
+	}
+	var ranges []csfmt.Range
+	if lines != nil {
+		ranges = csfmt.Ranges(d.text, lines)
+		if len(ranges) == 0 {
+			return []TextEdit{}, nil
+		}
+	}
+	formatted, err := s.Format(uriPath(uri), d.text, ranges)
+	if err != nil {
+		return nil, err
+	}
+	return textEdits(d.text, csfmt.Changes(d.text, formatted)), nil
+}
+
+// onType formats what the character typed before the position finished.
+func (s *Server) onType(params DocumentOnTypeFormattingParams) ([]TextEdit, error) {
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	line := params.Position.Line + 1
+	start := line
+	if params.Ch == "}" {
+		if opened, ok := opening(d.text, offset(d.text, params.Position)); ok {
+			start = opened
+		}
+	}
+	return s.edits(params.TextDocument.URI, []csfmt.LineRange{{Start: start, End: line}})
+}
+
+// opening returns the line of the brace opening the block closed by the
+// last token before the offset, when that is a closing brace.
+func opening(text []byte, at int) (int, bool) {
+	opened := []int{}
+	line, closed := 0, false
+	for _, token := range lexer.Lex(text) {
+		if token.Offset >= at {
+			break
+		}
+		switch {
+		case token.Is(lexer.Punctuation, "{"):
+			opened = append(opened, token.Line)
+			closed = false
+		case token.Is(lexer.Punctuation, "}"):
+			closed = false
+			if n := len(opened); n > 0 {
+				line, closed = opened[n-1], true
+				opened = opened[:n-1]
+			}
+		case !token.Kind.IsTrivia():
+			closed = false
+		}
+	}
+	return line, closed
+}
+
+// codeActions offers to fix each violation found within the range.
+func (s *Server) codeActions(params CodeActionParams) ([]CodeAction, error) {
+	actions := []CodeAction{}
+	if !wanted(QuickFix, params.Context.Only) {
+		return actions, nil
+	}
+	uri := params.TextDocument.URI
+	d, err := s.document(uri)
+	if err != nil {
+		return nil, err
+	}
+	found, err := s.Check(uriPath(uri), d.text)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := offset(d.text, params.Range.Start), offset(d.text, params.Range.End)
+	for _, f := range found {
+		if f.Fix == nil || f.Fix.End < start || f.Fix.Start > end {
+			continue
+		}
+		fixed := diagnostic(d.text, f)
+		actions = append(actions, CodeAction{
+			Title:       fmt.Sprintf("Fix %s: %s", fixed.Code, f.Message),
+			Kind:        QuickFix,
+			Diagnostics: []Diagnostic{fixed},
+			IsPreferred: true,
+			Edit: &WorkspaceEdit{Changes: map[string][]TextEdit{
+				uri: textEdits(d.text, []csfmt.Edit{*f.Fix}),
+			}},
+		})
+	}
+	return actions, nil
+}
+
+// wanted reports whether actions of the kind are wanted by an editor only
+// wanting some kinds, each of which includes the kinds beneath it.
+func wanted(kind string, only []string) bool {
+	if len(only) == 0 {
+		return true
+	}
+	for _, o := range only {
+		if kind == o || strings.HasPrefix(kind, o+".") {
+			return true
+		}
+	}
+	return false
+}
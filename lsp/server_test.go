@@ -0,0 +1,158 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/revolvingcow/csfmt"
+)
+
+func TestServe(t *testing.T) {
+	squeeze := &csfmt.Rule{
+		ID: "Squeeze",
+		Apply: func(source []byte) []byte {
+			lines := strings.SplitAfter(string(source), "\n")
+			for i, line := range lines {
+				indent := len(line) - len(strings.TrimLeft(line, " "))
+				lines[i] = line[:indent] + strings.Replace(line[indent:], "  ", " ", -1)
+			}
+			return []byte(strings.Join(lines, ""))
+		},
+	}
+	server := &Server{
+		Format: func(path string, source []byte, ranges []csfmt.Range) ([]byte, error) {
+			formatted, _, err := squeeze.FormatWithin(path, source, ranges)
+			return formatted, err
+		},
+		Check: squeeze.Check,
+	}
+
+	uri := "file:///src/Program.cs"
+	document := TextDocumentIdentifier{URI: uri}
+	input := &bytes.Buffer{}
+	send := func(id int, method string, params interface{}) {
+		m := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+		if id > 0 {
+			m["id"] = id
+		}
+		if err := (&conn{w: input}).write(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	line := func(n, start, end int) Range {
+		return Range{Start: Position{Line: n, Character: start}, End: Position{Line: n, Character: end}}
+	}
+
+	inserted := line(3, 7, 7)
+
+	send(1, "initialize", map[string]interface{}{})
+	send(0, "initialized", map[string]interface{}{})
+	send(0, "textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{
+		URI:     uri,
+		Version: 1,
+		Text:    "class A\n{\n    int  a;\n    int b;\n}\n",
+	}})
+	send(0, "textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Range: &inserted, Text: " "}},
+	})
+	send(2, "textDocument/rangeFormatting", DocumentRangeFormattingParams{TextDocument: document, Range: line(2, 0, 4)})
+	send(3, "textDocument/formatting", DocumentFormattingParams{TextDocument: document})
+	send(4, "textDocument/onTypeFormatting", DocumentOnTypeFormattingParams{TextDocument: document, Position: Position{Line: 3, Character: 11}, Ch: ";"})
+	send(5, "textDocument/codeAction", CodeActionParams{TextDocument: document, Range: line(3, 8, 8)})
+	send(6, "textDocument/hover", map[string]interface{}{})
+	send(7, "shutdown", nil)
+	send(0, "exit", nil)
+
+	output := &bytes.Buffer{}
+	if err := server.Serve(input, output); err != nil {
+		t.Fatal(err)
+	}
+
+	replies := map[string]*message{}
+	diagnostics := [][]Diagnostic{}
+	c := &conn{r: bufio.NewReader(output)}
+	for output.Len() > 0 || c.r.Buffered() > 0 {
+		m, err := c.read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Method == "textDocument/publishDiagnostics" {
+			var params PublishDiagnosticsParams
+			if err := json.Unmarshal(m.Params, &params); err != nil {
+				t.Fatal(err)
+			}
+			diagnostics = append(diagnostics, params.Diagnostics)
+			continue
+		}
+		replies[string(m.ID)] = m
+	}
+	result := func(id int, v interface{}) {
+		m := replies[fmt.Sprint(id)]
+		if m == nil {
+			t.Fatalf("Got no reply to %d", id)
+		}
+		if m.Error != nil {
+			t.Fatalf("Got `%s` replying to %d", m.Error, id)
+		}
+		if err := json.Unmarshal(m.Result, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var initialized InitializeResult
+	result(1, &initialized)
+	if !initialized.Capabilities.DocumentFormattingProvider || initialized.Capabilities.TextDocumentSync.Change != SyncIncremental {
+		t.Errorf("Got `%+v` but wanted formatting kept in sync incrementally", initialized.Capabilities)
+	}
+
+	if len(diagnostics) != 2 || len(diagnostics[0]) != 1 || len(diagnostics[1]) != 2 {
+		t.Fatalf("Got diagnostics `%+v` but wanted one after opening and two after changing", diagnostics)
+	}
+	expected := Diagnostic{Range: line(2, 8, 9), Severity: SeverityError, Code: "Squeeze", Source: "csfmt", Message: `remove " "`}
+	if !reflect.DeepEqual(expected, diagnostics[0][0]) {
+		t.Errorf("Got `%+v` but wanted `%+v`", diagnostics[0][0], expected)
+	}
+
+	edits := func(description string, id int, expected []TextEdit) {
+		var actual []TextEdit
+		result(id, &actual)
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("%s: got `%+v` but wanted `%+v`", description, actual, expected)
+		}
+	}
+	edits("range", 2, []TextEdit{{Range: line(2, 8, 9)}})
+	edits("document", 3, []TextEdit{{Range: line(2, 8, 9)}, {Range: line(3, 8, 9)}})
+	edits("on type", 4, []TextEdit{{Range: line(3, 8, 9)}})
+
+	var actions []CodeAction
+	result(5, &actions)
+	if len(actions) != 1 || actions[0].Kind != QuickFix || !reflect.DeepEqual(actions[0].Edit.Changes[uri], []TextEdit{{Range: line(3, 8, 9)}}) {
+		t.Errorf("Got `%+v` but wanted a quick fix for the fourth line", actions)
+	}
+
+	if m := replies["6"]; m == nil || m.Error == nil || m.Error.Code != MethodNotFound {
+		t.Errorf("Got `%+v` but wanted an unknown method refused", m)
+	}
+	if m := replies["7"]; m == nil || m.Error != nil || string(m.Result) != "null" {
+		t.Errorf("Got `%+v` but wanted shutting down to answer null", m)
+	}
+}
+
+func TestOpening(t *testing.T) {
+	text := []byte("class A\n{\n    void B()\n    {\n    }\n}\n")
+	if line, ok := opening(text, bytes.Index(text, []byte("    }"))+5); !ok || line != 4 {
+		t.Errorf("Got `%d` but wanted the block opened on line 4", line)
+	}
+	if line, ok := opening(text, len(text)); !ok || line != 2 {
+		t.Errorf("Got `%d` but wanted the block opened on line 2", line)
+	}
+	if _, ok := opening(text, bytes.Index(text, []byte("()"))); ok {
+		t.Errorf("Got a block but wanted none before a closing brace")
+	}
+}
@@ -0,0 +1,108 @@
+package lsp
+
+import (
+	"bytes"
+	"net/url"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/revolvingcow/csfmt"
+)
+
+// offset returns the byte offset of the position within the text.
+func offset(text []byte, p Position) int {
+	start := 0
+	for line := 0; line < p.Line; line++ {
+		i := bytes.IndexByte(text[start:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		start += i + 1
+	}
+
+	units := 0
+	for i := start; i < len(text); {
+		if units >= p.Character || text[i] == '\n' || (text[i] == '\r' && i+1 < len(text) && text[i+1] == '\n') {
+			return i
+		}
+		r, size := utf8.DecodeRune(text[i:])
+		units += utf16Len(r)
+		i += size
+	}
+	return len(text)
+}
+
+// position returns the position of the byte offset within the text.
+func position(text []byte, offset int) Position {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	before := text[:offset]
+	start := bytes.LastIndexByte(before, '\n') + 1
+
+	units := 0
+	for _, r := range string(before[start:]) {
+		units += utf16Len(r)
+	}
+	return Position{Line: bytes.Count(before, []byte("\n")), Character: units}
+}
+
+// utf16Len returns how many UTF-16 code units the rune takes.
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// applyChange returns the text with the change made to it.
+func applyChange(text []byte, change TextDocumentContentChangeEvent) []byte {
+	if change.Range == nil {
+		return []byte(change.Text)
+	}
+	start, end := offset(text, change.Range.Start), offset(text, change.Range.End)
+	if end < start {
+		end = start
+	}
+
+	changed := make([]byte, 0, len(text)-(end-start)+len(change.Text))
+	changed = append(changed, text[:start]...)
+	changed = append(changed, change.Text...)
+	return append(changed, text[end:]...)
+}
+
+// textEdits turns edits of the text into edits an editor understands.
+func textEdits(text []byte, edits []csfmt.Edit) []TextEdit {
+	found := []TextEdit{}
+	for _, edit := range edits {
+		found = append(found, TextEdit{
+			Range:   Range{Start: position(text, edit.Start), End: position(text, edit.End)},
+			NewText: string(edit.Text),
+		})
+	}
+	return found
+}
+
+// lines returns the lines the range covers. A range ending at the very
+// start of a line does not cover that line.
+func lines(r Range) csfmt.LineRange {
+	end := r.End.Line
+	if r.End.Character == 0 && end > r.Start.Line {
+		end--
+	}
+	return csfmt.LineRange{Start: r.Start.Line + 1, End: end + 1}
+}
+
+// uriPath returns the path of the file a URI names, or the URI itself when
+// it does not name a file.
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	path := u.Path
+	if len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
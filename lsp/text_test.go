@@ -0,0 +1,101 @@
+package lsp
+
+import (
+	"testing"
+)
+
+func TestPositions(t *testing.T) {
+	text := []byte("int a;\r\nstring é = \"😀\";\nlast")
+	tests := []struct {
+		description string
+		position    Position
+		offset      int
+	}{
+		{
+			description: "Start of the text",
+			position:    Position{Line: 0, Character: 0},
+			offset:      0,
+		},
+		{
+			description: "End of a line ending in a carriage return",
+			position:    Position{Line: 0, Character: 6},
+			offset:      6,
+		},
+		{
+			description: "After a character taking two bytes",
+			position:    Position{Line: 1, Character: 8},
+			offset:      17,
+		},
+		{
+			description: "After a character taking two UTF-16 code units",
+			position:    Position{Line: 1, Character: 15},
+			offset:      26,
+		},
+		{
+			description: "End of the text",
+			position:    Position{Line: 2, Character: 4},
+			offset:      len(text),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if actual := offset(text, test.position); actual != test.offset {
+				t.Errorf("Got offset `%d` but wanted `%d`", actual, test.offset)
+			}
+			if actual := position(text, test.offset); actual != test.position {
+				t.Errorf("Got position `%v` but wanted `%v`", actual, test.position)
+			}
+		})
+	}
+
+	if actual := offset(text, Position{Line: 0, Character: 40}); actual != 6 {
+		t.Errorf("Got `%d` but wanted a position past the end of a line at its end", actual)
+	}
+	if actual := offset(text, Position{Line: 9, Character: 0}); actual != len(text) {
+		t.Errorf("Got `%d` but wanted a position past the last line at the end", actual)
+	}
+}
+
+func TestApplyChange(t *testing.T) {
+	tests := []struct {
+		description string
+		given       string
+		change      TextDocumentContentChangeEvent
+		expected    string
+	}{
+		{
+			description: "Replacing the whole document",
+			given:       "int a;\n",
+			change:      TextDocumentContentChangeEvent{Text: "int b;\n"},
+			expected:    "int b;\n",
+		},
+		{
+			description: "Inserting",
+			given:       "int a;\n",
+			change: TextDocumentContentChangeEvent{
+				Range: &Range{Start: Position{Line: 0, Character: 5}, End: Position{Line: 0, Character: 5}},
+				Text:  "bc",
+			},
+			expected: "int abc;\n",
+		},
+		{
+			description: "Replacing across lines",
+			given:       "int a;\nint b;\nint c;\n",
+			change: TextDocumentContentChangeEvent{
+				Range: &Range{Start: Position{Line: 0, Character: 4}, End: Position{Line: 2, Character: 4}},
+				Text:  "",
+			},
+			expected: "int c;\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual := string(applyChange([]byte(test.given), test.change))
+			if actual != test.expected {
+				t.Errorf("Got `%q` but wanted `%q`", actual, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package csfmt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OptionType is the type of value an option holds.
+type OptionType string
+
+const (
+	OptionInt     OptionType = "int"
+	OptionBool    OptionType = "bool"
+	OptionStrings OptionType = "strings"
+	OptionChoice  OptionType = "choice"
+)
+
+// Option describes a single setting a rule accepts.
+type Option struct {
+	Name        string
+	Description string
+	Type        OptionType
+	Default     interface{}
+
+	// Choices lists the values allowed for a choice option.
+	Choices []string
+
+	// Validate checks a value beyond its type. It may be left unset.
+	Validate func(value interface{}) error
+}
+
+// Options holds the settings of a rule by name. Once parsed by the rule
+// each value has the type its option declares.
+type Options map[string]interface{}
+
+// Int returns the value of a parsed whole number option.
+func (o Options) Int(name string) int {
+	value, _ := o[name].(int)
+	return value
+}
+
+// Bool returns the value of a parsed boolean option.
+func (o Options) Bool(name string) bool {
+	value, _ := o[name].(bool)
+	return value
+}
+
+// Strings returns the value of a parsed list option.
+func (o Options) Strings(name string) []string {
+	value, _ := o[name].([]string)
+	return value
+}
+
+// Choice returns the value of a parsed choice option.
+func (o Options) Choice(name string) string {
+	value, _ := o[name].(string)
+	return value
+}
+
+// Parse converts a value found in configuration, or given on the command
+// line, to the type of the option.
+func (o Option) Parse(value interface{}) (interface{}, error) {
+	var parsed interface{}
+	switch o.Type {
+	case OptionInt:
+		switch v := value.(type) {
+		case int:
+			parsed = v
+		case float64:
+			if v == float64(int(v)) {
+				parsed = int(v)
+			}
+		case string:
+			if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				parsed = n
+			}
+		}
+		if parsed == nil {
+			return nil, fmt.Errorf("option %q must be a whole number", o.Name)
+		}
+	case OptionBool:
+		switch v := value.(type) {
+		case bool:
+			parsed = v
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				parsed = b
+			}
+		}
+		if parsed == nil {
+			return nil, fmt.Errorf("option %q must be true or false", o.Name)
+		}
+	case OptionStrings:
+		switch v := value.(type) {
+		case []string:
+			parsed = v
+		case []interface{}:
+			list := []string{}
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("option %q must be a list of strings", o.Name)
+				}
+				list = append(list, s)
+			}
+			parsed = list
+		case string:
+			// Lists given on the command line are separated by commas
+			list := []string{}
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+			parsed = list
+		default:
+			return nil, fmt.Errorf("option %q must be a list of strings", o.Name)
+		}
+	case OptionChoice:
+		v, _ := value.(string)
+		for _, choice := range o.Choices {
+			if strings.EqualFold(strings.TrimSpace(v), choice) {
+				parsed = choice
+			}
+		}
+		if parsed == nil {
+			return nil, fmt.Errorf("option %q must be one of %s", o.Name, strings.Join(o.Choices, ", "))
+		}
+	default:
+		return nil, fmt.Errorf("option %q has unknown type %q", o.Name, o.Type)
+	}
+
+	if o.Validate != nil {
+		if err := o.Validate(parsed); err != nil {
+			return nil, fmt.Errorf("option %q %s", o.Name, err)
+		}
+	}
+	return parsed, nil
+}
+
+// Option finds the option of the rule with the given name.
+func (r *Rule) Option(name string) (Option, bool) {
+	for _, option := range r.Options {
+		if option.Name == name {
+			return option, true
+		}
+	}
+	return Option{}, false
+}
+
+// ParseOptions checks every option is known to the rule and converts each
+// value to the type the rule declared for it.
+func (r *Rule) ParseOptions(options Options) (Options, error) {
+	parsed := Options{}
+	for name, value := range options {
+		option, ok := r.Option(name)
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown option %q", r.ID, name)
+		}
+		typed, err := option.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", r.ID, err)
+		}
+		parsed[name] = typed
+	}
+	return parsed, nil
+}
+
+// With returns the rule adjusted to the given options, using the default of
+// every option left out. The rule itself is left untouched so it may be
+// configured differently for each file.
+func (r *Rule) With(options Options) (*Rule, error) {
+	if len(options) == 0 {
+		return r, nil
+	}
+
+	effective, err := r.Effective(options)
+	if err != nil {
+		return nil, err
+	}
+
+	configured := *r
+	r.Configure(&configured, effective)
+	return &configured, nil
+}
+
+// Defaults returns the default of every option of the rule.
+func (r *Rule) Defaults() Options {
+	defaults := Options{}
+	for _, option := range r.Options {
+		defaults[option.Name] = option.Default
+	}
+	return defaults
+}
+
+// Effective returns the value every option of the rule takes under the
+// given options, falling back to the default of those left out.
+func (r *Rule) Effective(options Options) (Options, error) {
+	parsed, err := r.ParseOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range r.Defaults() {
+		if _, ok := parsed[name]; !ok {
+			parsed[name] = value
+		}
+	}
+	return parsed, nil
+}
+
+// AtLeast validates a whole number option is no smaller than the minimum.
+func AtLeast(minimum int) func(value interface{}) error {
+	return func(value interface{}) error {
+		if n, _ := value.(int); n < minimum {
+			return fmt.Errorf("must be at least %d", minimum)
+		}
+		return nil
+	}
+}
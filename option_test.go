@@ -0,0 +1,110 @@
+package csfmt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOptionParse(t *testing.T) {
+	width := Option{Name: "width", Type: OptionInt, Validate: AtLeast(1)}
+	flag := Option{Name: "flag", Type: OptionBool}
+	list := Option{Name: "list", Type: OptionStrings}
+	choice := Option{Name: "choice", Type: OptionChoice, Choices: []string{"lf", "crlf"}}
+
+	tests := []struct {
+		description string
+		option      Option
+		given       interface{}
+		expected    interface{}
+	}{
+		{description: "whole number", option: width, given: 2, expected: 2},
+		{description: "whole number from json", option: width, given: 2.0, expected: 2},
+		{description: "whole number from the command line", option: width, given: " 2", expected: 2},
+		{description: "fraction", option: width, given: 2.5, expected: nil},
+		{description: "not a number", option: width, given: "wide", expected: nil},
+		{description: "too small", option: width, given: 0, expected: nil},
+		{description: "boolean", option: flag, given: true, expected: true},
+		{description: "boolean from the command line", option: flag, given: "false", expected: false},
+		{description: "not a boolean", option: flag, given: 1.0, expected: nil},
+		{description: "list", option: list, given: []string{"a"}, expected: []string{"a"}},
+		{description: "list from json", option: list, given: []interface{}{"a", "b"}, expected: []string{"a", "b"}},
+		{description: "list from the command line", option: list, given: "a, b,", expected: []string{"a", "b"}},
+		{description: "list of numbers", option: list, given: []interface{}{"a", 1.0}, expected: nil},
+		{description: "choice", option: choice, given: "CRLF", expected: "crlf"},
+		{description: "not a choice", option: choice, given: "cr", expected: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual, err := test.option.Parse(test.given)
+			if test.expected == nil {
+				if err == nil {
+					t.Errorf("Got `%v` but wanted an error", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(test.expected, actual) {
+				t.Errorf("Got `%v` but wanted `%v`", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestWith(t *testing.T) {
+	var configured Options
+	rule := &Rule{
+		ID: "A",
+		Options: []Option{
+			{Name: "width", Type: OptionInt, Default: 4},
+			{Name: "flag", Type: OptionBool, Default: true},
+		},
+		Configure: func(rule *Rule, options Options) {
+			configured = options
+			rule.Name = "configured"
+		},
+	}
+
+	same, err := rule.With(nil)
+	if err != nil || same != rule {
+		t.Errorf("Got a different rule without any options")
+	}
+
+	actual, err := rule.With(Options{"width": 2.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual == rule || actual.Name != "configured" || rule.Name != "" {
+		t.Errorf("Got the library rule changed in place")
+	}
+	if expected := (Options{"width": 2, "flag": true}); !reflect.DeepEqual(expected, configured) {
+		t.Errorf("Got `%v` but wanted `%v`", configured, expected)
+	}
+
+	if _, err := rule.With(Options{"height": 2}); err == nil {
+		t.Errorf("Got no error for an unknown option but wanted one")
+	}
+}
+
+func TestEffective(t *testing.T) {
+	rule := &Rule{
+		ID: "A",
+		Options: []Option{
+			{Name: "width", Type: OptionInt, Default: 4},
+			{Name: "flag", Type: OptionBool, Default: true},
+		},
+	}
+
+	if expected, actual := (Options{"width": 4, "flag": true}), rule.Defaults(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Got `%v` but wanted `%v`", actual, expected)
+	}
+	actual, err := rule.Effective(Options{"flag": "false"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (Options{"width": 4, "flag": false}); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Got `%v` but wanted `%v`", actual, expected)
+	}
+}
@@ -0,0 +1,299 @@
+// Package project works out the source files compiled by .NET projects and
+// solutions.
+package project
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// IsProject reports whether the path names a project or solution file.
+func IsProject(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csproj", ".sln", ".slnx":
+		return true
+	}
+	return false
+}
+
+// Files returns the source files compiled by the project or solution found
+// at path, each only once, along with the files it names which do not exist.
+func Files(path string) ([]string, []string, error) {
+	projects := []string{path}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".sln":
+		found, err := solution(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		projects = found
+	case ".slnx":
+		found, err := xmlSolution(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		projects = found
+	}
+
+	seen := map[string]bool{}
+	files, missing := []string{}, []string{}
+	for _, project := range projects {
+		compiled, err := compile(project)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, file := range compiled {
+			if seen[file] {
+				continue
+			}
+			seen[file] = true
+			if _, err := os.Stat(file); os.IsNotExist(err) {
+				missing = append(missing, file)
+			} else {
+				files = append(files, file)
+			}
+		}
+	}
+	return files, missing, nil
+}
+
+var solutionProject = regexp.MustCompile(`^Project\("[^"]*"\)\s*=\s*"[^"]*"\s*,\s*"([^"]*)"`)
+
+// solution returns the paths of the C# projects of a solution.
+func solution(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	projects := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		match := solutionProject.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match != nil && strings.EqualFold(filepath.Ext(match[1]), ".csproj") {
+			projects = append(projects, relative(filepath.Dir(path), match[1]))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// xmlSolution returns the paths of the C# projects of an XML solution.
+func xmlSolution(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	projects := []string{}
+	decoder := xml.NewDecoder(f)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				return projects, nil
+			}
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok || element.Name.Local != "Project" {
+			continue
+		}
+		for _, attr := range element.Attr {
+			if attr.Name.Local == "Path" && strings.EqualFold(filepath.Ext(attr.Value), ".csproj") {
+				projects = append(projects, relative(filepath.Dir(path), attr.Value))
+			}
+		}
+	}
+}
+
+// relative joins a path written in a project or solution, possibly with
+// backslashes, to the directory holding the file it was written in.
+func relative(dir, path string) string {
+	return filepath.Join(dir, filepath.FromSlash(strings.Replace(path, `\`, "/", -1)))
+}
+
+// projectFile holds what a project file says about the files it compiles.
+type projectFile struct {
+	Sdk  string `xml:"Sdk,attr"`
+	Sdks []struct {
+		Name string `xml:"Name,attr"`
+	} `xml:"Sdk"`
+	PropertyGroups []struct {
+		EnableDefaultItems        string
+		EnableDefaultCompileItems string
+	} `xml:"PropertyGroup"`
+	ItemGroups []struct {
+		Compile []struct {
+			Include string `xml:"Include,attr"`
+			Exclude string `xml:"Exclude,attr"`
+			Remove  string `xml:"Remove,attr"`
+		}
+	} `xml:"ItemGroup"`
+}
+
+// The items compiled by SDK style projects unless told otherwise, and the
+// files left out of them.
+const (
+	defaultCompileItems  = "**/*.cs"
+	defaultItemsExcludes = "bin/**;obj/**;**/.*/**"
+)
+
+// compile returns the source files compiled by the project found at path.
+func compile(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := projectFile{}
+	if err := xml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	dir := filepath.Dir(path)
+
+	files := []string{}
+	include := func(specs, excludes string) error {
+		for _, spec := range split(specs) {
+			found, err := expand(dir, spec)
+			if err != nil {
+				return err
+			}
+			for _, file := range found {
+				if !matchesAny(dir, excludes, file) {
+					files = append(files, file)
+				}
+			}
+		}
+		return nil
+	}
+
+	defaults := p.Sdk != "" || len(p.Sdks) > 0
+	for _, group := range p.PropertyGroups {
+		if strings.EqualFold(strings.TrimSpace(group.EnableDefaultItems), "false") ||
+			strings.EqualFold(strings.TrimSpace(group.EnableDefaultCompileItems), "false") {
+			defaults = false
+		}
+	}
+	if defaults {
+		if err := include(defaultCompileItems, defaultItemsExcludes); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, group := range p.ItemGroups {
+		for _, item := range group.Compile {
+			if item.Include != "" {
+				if err := include(item.Include, item.Exclude); err != nil {
+					return nil, err
+				}
+			}
+			if item.Remove != "" {
+				kept := []string{}
+				for _, file := range files {
+					if !matchesAny(dir, item.Remove, file) {
+						kept = append(kept, file)
+					}
+				}
+				files = kept
+			}
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// split returns each item specification of a list, with the properties
+// naming the project directory filled in.
+func split(specs string) []string {
+	found := []string{}
+	for _, spec := range strings.Split(specs, ";") {
+		spec = strings.TrimSpace(spec)
+		for _, property := range []string{"$(MSBuildProjectDirectory)", "$(MSBuildThisFileDirectory)"} {
+			spec = strings.Replace(spec, property, ".", -1)
+		}
+		if spec == "" || strings.ContainsAny(spec, "$@%") {
+			continue
+		}
+		found = append(found, strings.Replace(spec, `\`, "/", -1))
+	}
+	return found
+}
+
+// expand returns the files an item specification includes from the
+// directory of the project.
+func expand(dir, spec string) ([]string, error) {
+	if !strings.ContainsAny(spec, "*?") {
+		return []string{relative(dir, spec)}, nil
+	}
+
+	// Walk from the deepest directory without any wildcard
+	parts := strings.Split(spec, "/")
+	fixed := 0
+	for fixed < len(parts)-1 && !strings.ContainsAny(parts[fixed], "*?") {
+		fixed++
+	}
+	root := relative(dir, strings.Join(parts[:fixed], "/"))
+	re := wildcard(relative(dir, spec))
+
+	files := []string{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() && re.MatchString(filepath.ToSlash(path)) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// matchesAny reports whether the file is one of the items of the list.
+func matchesAny(dir, specs, file string) bool {
+	for _, spec := range split(specs) {
+		if wildcard(relative(dir, spec)).MatchString(filepath.ToSlash(file)) {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcard turns a path using wildcards into a regular expression matching
+// paths separated by forward slashes.
+func wildcard(path string) *regexp.Regexp {
+	path = filepath.ToSlash(path)
+	out := &strings.Builder{}
+	for i := 0; i < len(path); i++ {
+		switch {
+		case strings.HasPrefix(path[i:], "**/"):
+			out.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(path[i:], "**"):
+			out.WriteString(".*")
+			i++
+		case path[i] == '*':
+			out.WriteString("[^/]*")
+		case path[i] == '?':
+			out.WriteString("[^/]")
+		default:
+			out.WriteString(regexp.QuoteMeta(path[i : i+1]))
+		}
+	}
+	return regexp.MustCompile("^" + out.String() + "$")
+}
@@ -0,0 +1,114 @@
+package project
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csfmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"All.sln": "Microsoft Visual Studio Solution File, Format Version 12.00\n" +
+			"Project(\"{2150E333-8FDC-42A3-9474-1A3956D46DE8}\") = \"Shared\", \"Shared\", \"{1}\"\n" +
+			"EndProject\n" +
+			"Project(\"{9A19103F-16F7-4668-BE54-9A1E7A4F7556}\") = \"App\", \"App\\App.csproj\", \"{2}\"\n" +
+			"EndProject\n" +
+			"Project(\"{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}\") = \"Old\", \"Old\\Old.csproj\", \"{3}\"\n" +
+			"EndProject\n",
+		"All.slnx": "<Solution>\n  <Folder Name=\"/src/\">\n    <Project Path=\"App/App.csproj\" />\n  </Folder>\n</Solution>\n",
+		filepath.Join("App", "App.csproj"): "<Project Sdk=\"Microsoft.NET.Sdk\">\n" +
+			"  <ItemGroup>\n" +
+			"    <Compile Remove=\"Legacy\\**\" />\n" +
+			"    <Compile Include=\"..\\Shared\\*.cs\" Exclude=\"..\\Shared\\Draft.cs\" Link=\"%(Filename)\" />\n" +
+			"    <Compile Include=\"$(SharedDir)\\Other.cs\" />\n" +
+			"  </ItemGroup>\n" +
+			"</Project>\n",
+		filepath.Join("App", "Program.cs"):                 "",
+		filepath.Join("App", "Models", "User.cs"):          "",
+		filepath.Join("App", "Legacy", "Old.cs"):           "",
+		filepath.Join("App", "bin", "Debug", "Gen.cs"):     "",
+		filepath.Join("App", "obj", "App.AssemblyInfo.cs"): "",
+		filepath.Join("App", ".vs", "Hidden.cs"):           "",
+		filepath.Join("App", "README.md"):                  "",
+		filepath.Join("Old", "Old.csproj"): "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n" +
+			"<Project ToolsVersion=\"15.0\" xmlns=\"http://schemas.microsoft.com/developer/msbuild/2003\">\n" +
+			"  <ItemGroup>\n" +
+			"    <Compile Include=\"Form.cs;Form.Designer.cs;Gone.cs\" />\n" +
+			"    <Compile Include=\"..\\Shared\\Version.cs\">\n" +
+			"      <Link>Version.cs</Link>\n" +
+			"    </Compile>\n" +
+			"  </ItemGroup>\n" +
+			"</Project>\n",
+		filepath.Join("Old", "Form.cs"):          "",
+		filepath.Join("Old", "Form.Designer.cs"): "",
+		filepath.Join("Old", "Unused.cs"):        "",
+		filepath.Join("Shared", "Version.cs"):    "",
+		filepath.Join("Shared", "Draft.cs"):      "",
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	app := []string{
+		filepath.Join("App", "Models", "User.cs"),
+		filepath.Join("App", "Program.cs"),
+		filepath.Join("Shared", "Version.cs"),
+	}
+	old := []string{
+		filepath.Join("Old", "Form.Designer.cs"),
+		filepath.Join("Old", "Form.cs"),
+		filepath.Join("Shared", "Version.cs"),
+	}
+	gone := []string{filepath.Join("Old", "Gone.cs")}
+	tests := []struct {
+		description string
+		given       string
+		expected    []string
+		missing     []string
+	}{
+		{description: "sdk style project", given: filepath.Join("App", "App.csproj"), expected: app, missing: []string{}},
+		{description: "old style project", given: filepath.Join("Old", "Old.csproj"), expected: old, missing: gone},
+		{description: "solution", given: "All.sln", expected: append(append([]string{}, app...), old[:2]...), missing: gone},
+		{description: "xml solution", given: "All.slnx", expected: app, missing: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			found, missing, err := Files(filepath.Join(dir, test.given))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rel := func(files []string) []string {
+				actual := []string{}
+				for _, file := range files {
+					rel, err := filepath.Rel(dir, file)
+					if err != nil {
+						t.Fatal(err)
+					}
+					actual = append(actual, rel)
+				}
+				return actual
+			}
+			if actual := rel(found); !reflect.DeepEqual(test.expected, actual) {
+				t.Errorf("Got `%v` but wanted `%v`", actual, test.expected)
+			}
+			if actual := rel(missing); !reflect.DeepEqual(test.missing, actual) {
+				t.Errorf("Got `%v` missing but wanted `%v`", actual, test.missing)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package csfmt
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LineRange is the lines of a file from Start to End, both counted from 1
+// and included.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+func (l LineRange) String() string {
+	return fmt.Sprintf("%d:%d", l.Start, l.End)
+}
+
+// Range is the part of a source from the byte at Start up to End.
+type Range struct {
+	Start int
+	End   int
+}
+
+// ParseLineRange reads a range of lines written as start:end, or a single
+// line.
+func ParseLineRange(text string) (LineRange, error) {
+	parts := strings.SplitN(text, ":", 2)
+	if len(parts) == 1 {
+		parts = append(parts, parts[0])
+	}
+	start, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return LineRange{}, fmt.Errorf("lines %q must be written as start:end", text)
+	}
+	end, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return LineRange{}, fmt.Errorf("lines %q must be written as start:end", text)
+	}
+	if start < 1 || end < start {
+		return LineRange{}, fmt.Errorf("lines %q must start at 1 or later and not end before they start", text)
+	}
+	return LineRange{Start: start, End: end}, nil
+}
+
+// Ranges returns the parts of the source holding the lines, in order. The
+// result is never nil, even when none of the lines exist.
+func Ranges(source []byte, lines []LineRange) []Range {
+	starts := []int{0}
+	for i, c := range source {
+		if c == '\n' && i+1 < len(source) {
+			starts = append(starts, i+1)
+		}
+	}
+	offset := func(line int) int {
+		if line > len(starts) {
+			return len(source)
+		}
+		return starts[line-1]
+	}
+
+	found := []Range{}
+	for _, l := range lines {
+		if l.Start > len(starts) {
+			continue
+		}
+		end := len(source)
+		if l.End < len(starts) {
+			end = offset(l.End + 1)
+		}
+		found = append(found, Range{Start: offset(l.Start), End: end})
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Start < found[j].Start
+	})
+
+	merged := []Range{}
+	for _, r := range found {
+		if last := len(merged) - 1; last >= 0 && r.Start <= merged[last].End {
+			if r.End > merged[last].End {
+				merged[last].End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// Within reports whether the edit only changes the source inside one of
+// the ranges.
+func (e Edit) Within(ranges []Range) bool {
+	for _, r := range ranges {
+		if e.Start >= r.Start && e.End <= r.End && e.Start < r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// moveRanges returns where the ranges are found once the edits, all made
+// within them, have been applied.
+func moveRanges(ranges []Range, edits []Edit) []Range {
+	if ranges == nil {
+		return nil
+	}
+
+	moved := make([]Range, len(ranges))
+	for i, r := range ranges {
+		moved[i] = r
+		for _, edit := range edits {
+			change := len(edit.Text) - (edit.End - edit.Start)
+			if edit.Start < r.Start {
+				moved[i].Start += change
+			}
+			if edit.Start < r.End {
+				moved[i].End += change
+			}
+		}
+	}
+	return moved
+}
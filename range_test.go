@@ -0,0 +1,95 @@
+package csfmt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestRanges(t *testing.T) {
+	source := []byte("a;\nb;\r\nc;\nd;")
+
+	tests := []struct {
+		description string
+		given       []LineRange
+		expected    []Range
+	}{
+		{description: "one line", given: []LineRange{{2, 2}}, expected: []Range{{3, 7}}},
+		{description: "last line", given: []LineRange{{4, 4}}, expected: []Range{{10, 12}}},
+		{description: "past the end", given: []LineRange{{3, 9}, {5, 6}}, expected: []Range{{7, 12}}},
+		{description: "merged", given: []LineRange{{3, 3}, {1, 1}, {2, 2}}, expected: []Range{{0, 10}}},
+		{description: "none", given: nil, expected: []Range{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual := Ranges(source, test.given)
+			if !reflect.DeepEqual(test.expected, actual) {
+				t.Errorf("Got `%v` but wanted `%v`", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseLineRange(t *testing.T) {
+	tests := []struct {
+		given    string
+		expected LineRange
+	}{
+		{given: "3:7", expected: LineRange{3, 7}},
+		{given: "5", expected: LineRange{5, 5}},
+		{given: "0:2"},
+		{given: "7:3"},
+		{given: "a:b"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.given, func(t *testing.T) {
+			actual, err := ParseLineRange(test.given)
+			if test.expected == (LineRange{}) {
+				if err == nil {
+					t.Errorf("Got `%v` but wanted an error", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != test.expected {
+				t.Errorf("Got `%v` but wanted `%v`", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFormatWithin(t *testing.T) {
+	// Doubles every tab, which moves the lines after it
+	rule := &Rule{
+		ID: "A",
+		Apply: func(source []byte) []byte {
+			return bytes.Replace(source, []byte("\t"), []byte("\t\t"), -1)
+		},
+	}
+	source := []byte("\ta;\n\tb;\n\tc;\n\td;\n")
+
+	ranges := Ranges(source, []LineRange{{2, 2}, {4, 4}})
+	actual, moved, err := rule.FormatWithin("", source, ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "\ta;\n\t\tb;\n\tc;\n\t\td;\n"; string(actual) != expected {
+		t.Errorf("Got `%q` but wanted `%q`", actual, expected)
+	}
+	if expected := Ranges(actual, []LineRange{{2, 2}, {4, 4}}); !reflect.DeepEqual(expected, moved) {
+		t.Errorf("Got `%v` but wanted `%v`", moved, expected)
+	}
+
+	// Formatting again only touches the same lines
+	again, _, err := rule.FormatWithin("", actual, moved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "\ta;\n\t\t\t\tb;\n\tc;\n\t\t\t\td;\n"; string(again) != expected {
+		t.Errorf("Got `%q` but wanted `%q`", again, expected)
+	}
+}
@@ -1,9 +1,114 @@
 package csfmt
 
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/revolvingcow/csfmt/lexer"
+)
+
 // Rule is a style rule to look for and apply within the source code.
 type Rule struct {
+	ID          string
+	Code        string
 	Name        string
 	Description string
 	Enabled     bool
 	Apply       func(source []byte) []byte
+	Edit        func(file *File) []Edit
+
+	// Options describes the settings the rule accepts and Configure adjusts
+	// a copy of the rule to them. Rules without any options leave both unset.
+	Options   []Option
+	Configure func(rule *Rule, options Options)
+}
+
+// File is the context handed to token based rules.
+type File struct {
+	Path   string
+	Source []byte
+	Tokens []lexer.Token
+}
+
+// NewFile lexes the source of the file found at path.
+func NewFile(path string, source []byte) *File {
+	return &File{
+		Path:   path,
+		Source: source,
+		Tokens: lexer.Lex(source),
+	}
+}
+
+// Edit replaces the bytes of the source from Start up to End with Text.
+type Edit struct {
+	Start int
+	End   int
+	Text  []byte
+}
+
+// ApplyEdits returns a copy of the source with every edit applied.
+func ApplyEdits(source []byte, edits []Edit) ([]byte, error) {
+	sorted := make([]Edit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+
+	for i, edit := range sorted {
+		if edit.Start < 0 || edit.End < edit.Start || edit.End > len(source) {
+			return nil, fmt.Errorf("edit %d:%d is outside of the source", edit.Start, edit.End)
+		}
+		if i > 0 && sorted[i-1].End > edit.Start {
+			return nil, fmt.Errorf("edit %d:%d overlaps edit %d:%d", edit.Start, edit.End, sorted[i-1].Start, sorted[i-1].End)
+		}
+	}
+
+	result := append([]byte{}, source...)
+	for i := len(sorted) - 1; i >= 0; i-- {
+		edit := sorted[i]
+		tail := append(append([]byte{}, edit.Text...), result[edit.End:]...)
+		result = append(result[:edit.Start], tail...)
+	}
+	return result, nil
+}
+
+// Format applies the rule to the source of the file found at path.
+func (r *Rule) Format(path string, source []byte) ([]byte, error) {
+	formatted, _, err := r.FormatWithin(path, source, nil)
+	return formatted, err
+}
+
+// FormatWithin applies the rule to the source of the file found at path,
+// only changing it within the ranges, and returns where the ranges are
+// found in the result. Nil ranges cover the whole source.
+func (r *Rule) FormatWithin(path string, source []byte, ranges []Range) ([]byte, []Range, error) {
+	suppressions := r.suppressions(source)
+	whole := len(suppressions) == 0 && ranges == nil
+	var edits []Edit
+	switch {
+	case r.Edit != nil:
+		edits = r.Edit(NewFile(path, source))
+	case whole && bytes.IndexByte(source, '\r') < 0:
+		return r.Apply(source), nil, nil
+	case whole && allCRLF(source):
+		return r.applyCRLF(source), nil, nil
+	default:
+		edits = r.applyEdits(source)
+	}
+	kept := []Edit{}
+	for _, edit := range unsuppressed(edits, suppressions) {
+		if ranges == nil || edit.Within(ranges) {
+			kept = append(kept, edit)
+		}
+	}
+
+	formatted, err := ApplyEdits(source, kept)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", r.ID, err)
+	}
+	if len(kept) < len(edits) && SameCode(source, formatted) != nil {
+		return source, ranges, nil
+	}
+	return formatted, moveRanges(ranges, kept), nil
 }
@@ -1,40 +1,35 @@
 package rules
 
 import (
-	"bytes"
 	"regexp"
 
 	"github.com/revolvingcow/csfmt"
 )
 
 var closingParenthesisMustBeSpacedCorrectly = &csfmt.Rule{
+	ID:          "ClosingParenthesisMustBeSpacedCorrectly",
+	Code:        "SA1009",
 	Name:        "Closing parenthesis must be spaced correctly",
 	Enabled:     true,
 	Apply:       applyClosingParenthesisMustBeSpacedCorrectly,
-	Description: ``,
+	Description: `A violation of this rule occurs when the closing parenthesis within a statement is not spaced correctly.`,
 }
 
 func applyClosingParenthesisMustBeSpacedCorrectly(source []byte) []byte {
-	return scan(source, func(line, literal []byte) []byte {
-		spaceBetween := `([A-z]|=|\+|\-|\*|/|&|\||\^|\{)`
+	return scan(source, func(line []byte) []byte {
+		spaceBetween := `([A-Za-z]|=|\+|\-|\*|/|&|\||\^|\{)`
 
 		// Remove leading spaces
 		re := regexp.MustCompile(`([\S])(\t| )([\)])`)
-		if !bytes.Contains(literal, re.Find(line)) {
-			line = re.ReplaceAll(line, []byte("$1$3"))
-		}
+		line = re.ReplaceAll(line, []byte("$1$3"))
 
 		// Remove trailing spaces
 		re = regexp.MustCompile(`([\)])(\t| )([\S])`)
-		if !bytes.Contains(literal, re.Find(line)) {
-			line = re.ReplaceAll(line, []byte("$1$3"))
-		}
+		line = re.ReplaceAll(line, []byte("$1$3"))
 
 		// Add space between operators and keywords
 		re = regexp.MustCompile(`([\)])` + spaceBetween)
-		if !bytes.Contains(literal, re.Find(line)) {
-			line = re.ReplaceAll(line, []byte("$1 $2"))
-		}
+		line = re.ReplaceAll(line, []byte("$1 $2"))
 
 		return line
 	})
@@ -16,6 +16,7 @@ func TestClosingParenthesisMustBeSpacedCorrectly(t *testing.T) {
 		{description: "function leading space only", given: []byte("public void something(int i ) {}"), expected: []byte("public void something(int i) {}")},
 		{description: "switch statement leading space and no trailing", given: []byte("switch (foo ){}"), expected: []byte("switch (foo) {}")},
 		{description: "in arithmetic", given: []byte("(2)+1"), expected: []byte("(2) +1")},
+		{description: "closing an attribute", given: []byte("[Foo(1)]"), expected: []byte("[Foo(1)]")},
 	}
 
 	for _, test := range tests {
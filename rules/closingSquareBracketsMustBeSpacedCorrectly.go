@@ -1,34 +1,29 @@
 package rules
 
 import (
-	"bytes"
 	"regexp"
 
 	"github.com/revolvingcow/csfmt"
 )
 
 var closingSquareBracketsMustBeSpacedCorrectly = &csfmt.Rule{
+	ID:          "ClosingSquareBracketsMustBeSpacedCorrectly",
+	Code:        "SA1011",
 	Name:        "Closing square brackets must be spaced correctly",
 	Enabled:     true,
 	Apply:       applyClosingSquareBracketsMustBeSpacedCorrectly,
-	Description: ``,
+	Description: `A violation of this rule occurs when a closing square bracket within a statement is not spaced correctly.`,
 }
 
 func applyClosingSquareBracketsMustBeSpacedCorrectly(source []byte) []byte {
-	return scan(source, func(line, literal []byte) []byte {
+	return scan(source, func(line []byte) []byte {
 		re := regexp.MustCompile(`([\S])([\t ]+)([\]])`)
-		if !bytes.Contains(literal, re.Find(line)) {
-			line = re.ReplaceAll(line, []byte("$1$3"))
-		}
+		line = re.ReplaceAll(line, []byte("$1$3"))
 
 		re = regexp.MustCompile(`([\]])([\S])`)
-		if !bytes.Contains(literal, re.Find(line)) {
-			line = re.ReplaceAll(line, []byte("$1 $2"))
-		}
+		line = re.ReplaceAll(line, []byte("$1 $2"))
 		re = regexp.MustCompile(`([\]]) ([;])`)
-		if !bytes.Contains(literal, re.Find(line)) {
-			line = re.ReplaceAll(line, []byte("$1$2"))
-		}
+		line = re.ReplaceAll(line, []byte("$1$2"))
 
 		return line
 	})
@@ -0,0 +1,68 @@
+package rules
+
+import (
+	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/lexer"
+)
+
+var codeMustNotContainBlankLinesAtEndOfFile = configured(&csfmt.Rule{
+	ID:        "CodeMustNotContainBlankLinesAtEndOfFile",
+	Code:      "SA1518",
+	Name:      "Code must not contain blank lines at end of file",
+	Enabled:   true,
+	Configure: configureCodeMustNotContainBlankLinesAtEndOfFile,
+	Options: []csfmt.Option{
+		{
+			Name:        "final-newline",
+			Description: "whether the file must end with a line break: allow, require or omit",
+			Type:        csfmt.OptionChoice,
+			Choices:     []string{"allow", "require", "omit"},
+			Default:     "allow",
+		},
+	},
+	Description: `A violation of this rule occurs when the file ends with blank lines or whitespace, or breaks the final line break setting.`,
+})
+
+// configureCodeMustNotContainBlankLinesAtEndOfFile ends each file as the
+// "final-newline" option says.
+func configureCodeMustNotContainBlankLinesAtEndOfFile(rule *csfmt.Rule, options csfmt.Options) {
+	finalNewline := options.Choice("final-newline")
+	rule.Edit = func(file *csfmt.File) []csfmt.Edit {
+		return endOfFileEdits(file, finalNewline)
+	}
+}
+
+// endOfFileEdits replaces everything after the last token of the file with
+// the line break the final newline setting asks for.
+func endOfFileEdits(file *csfmt.File, finalNewline string) []csfmt.Edit {
+	tokens := file.Tokens
+	last := len(tokens)
+	for last > 0 && isSpace(tokens[last-1]) {
+		last--
+	}
+	if last == 0 {
+		return []csfmt.Edit{}
+	}
+
+	ending := ""
+	for _, token := range tokens[last:] {
+		if token.Kind == lexer.Newline {
+			ending = string(token.Text)
+			break
+		}
+	}
+	switch finalNewline {
+	case "require":
+		if ending == "" {
+			detected, _ := csfmt.DetectLineEnding(file.Source)
+			ending = string(detected)
+		}
+	case "omit":
+		ending = ""
+	}
+
+	if edit, ok := replace(file, last, len(tokens), ending); ok {
+		return []csfmt.Edit{edit}
+	}
+	return []csfmt.Edit{}
+}
@@ -0,0 +1,68 @@
+package rules
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/revolvingcow/csfmt"
+)
+
+func TestCodeMustNotContainBlankLinesAtEndOfFile(t *testing.T) {
+	tests := []struct {
+		description string
+		options     csfmt.Options
+		given       []byte
+		expected    []byte
+	}{
+		{
+			description: "remove blank lines",
+			given:       []byte("class A\n{\n}\n\n  \n"),
+			expected:    []byte("class A\n{\n}\n"),
+		},
+		{
+			description: "allow a missing line break",
+			given:       []byte("class A\n{\n}  "),
+			expected:    []byte("class A\n{\n}"),
+		},
+		{
+			description: "require a line break",
+			options:     csfmt.Options{"final-newline": "require"},
+			given:       []byte("class A\r\n{\r\n}"),
+			expected:    []byte("class A\r\n{\r\n}\r\n"),
+		},
+		{
+			description: "keep the line break of the file",
+			options:     csfmt.Options{"final-newline": "require"},
+			given:       []byte("class A {} // end\r\n\r\n"),
+			expected:    []byte("class A {} // end\r\n"),
+		},
+		{
+			description: "omit the line break",
+			options:     csfmt.Options{"final-newline": "omit"},
+			given:       []byte("class A\n{\n}\n\n"),
+			expected:    []byte("class A\n{\n}"),
+		},
+		{
+			description: "nothing but whitespace",
+			options:     csfmt.Options{"final-newline": "require"},
+			given:       []byte("\n\n"),
+			expected:    []byte("\n\n"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			rule, err := codeMustNotContainBlankLinesAtEndOfFile.With(test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := rule.Format("", test.given)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(test.expected, actual) {
+				t.Errorf("Got `%q` but wanted `%q`", actual, test.expected)
+			}
+		})
+	}
+}
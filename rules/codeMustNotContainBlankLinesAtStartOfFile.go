@@ -0,0 +1,32 @@
+package rules
+
+import (
+	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/lexer"
+)
+
+var codeMustNotContainBlankLinesAtStartOfFile = &csfmt.Rule{
+	ID:          "CodeMustNotContainBlankLinesAtStartOfFile",
+	Code:        "SA1517",
+	Name:        "Code must not contain blank lines at start of file",
+	Enabled:     true,
+	Edit:        editCodeMustNotContainBlankLinesAtStartOfFile,
+	Description: `A violation of this rule occurs when the file begins with blank lines or whitespace.`,
+}
+
+func editCodeMustNotContainBlankLinesAtStartOfFile(file *csfmt.File) []csfmt.Edit {
+	first := 0
+	for first < len(file.Tokens) && isSpace(file.Tokens[first]) {
+		first++
+	}
+	if edit, ok := replace(file, 0, first, ""); ok {
+		return []csfmt.Edit{edit}
+	}
+	return []csfmt.Edit{}
+}
+
+// isSpace reports whether the token is nothing but whitespace or a line
+// break.
+func isSpace(token lexer.Token) bool {
+	return token.Kind == lexer.Whitespace || token.Kind == lexer.Newline
+}
@@ -0,0 +1,47 @@
+package rules
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCodeMustNotContainBlankLinesAtStartOfFile(t *testing.T) {
+	tests := []struct {
+		description string
+		given       []byte
+		expected    []byte
+	}{
+		{
+			description: "remove blank lines",
+			given:       []byte("\r\n  \r\nusing System;\r\n"),
+			expected:    []byte("using System;\r\n"),
+		},
+		{
+			description: "remove indentation of the first line",
+			given:       []byte("   using System;\n"),
+			expected:    []byte("using System;\n"),
+		},
+		{
+			description: "keep the file header",
+			given:       []byte("// Copyright\n\nusing System;\n"),
+			expected:    []byte("// Copyright\n\nusing System;\n"),
+		},
+		{
+			description: "nothing but whitespace",
+			given:       []byte("\n\n"),
+			expected:    []byte(""),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual, err := codeMustNotContainBlankLinesAtStartOfFile.Format("", test.given)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(test.expected, actual) {
+				t.Errorf("Got `%q` but wanted `%q`", actual, test.expected)
+			}
+		})
+	}
+}
@@ -1,22 +1,60 @@
 package rules
 
 import (
-	"regexp"
-
 	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/lexer"
 )
 
-var codeMustNotContainMultipleBlankLinesInARow = &csfmt.Rule{
-	Name:        "Code must not contain multiple blank lines in a row",
-	Enabled:     true,
-	Apply:       applyCodeMustNotContainMultipleBlankLinesInARow,
-	Description: ``,
+var codeMustNotContainMultipleBlankLinesInARow = configured(&csfmt.Rule{
+	ID:        "CodeMustNotContainMultipleBlankLinesInARow",
+	Code:      "SA1507",
+	Name:      "Code must not contain multiple blank lines in a row",
+	Enabled:   true,
+	Configure: configureCodeMustNotContainMultipleBlankLinesInARow,
+	Options: []csfmt.Option{
+		{
+			Name:        "maximum-blank-lines",
+			Description: "most blank lines allowed in a row before they are removed",
+			Type:        csfmt.OptionInt,
+			Default:     1,
+			Validate:    csfmt.AtLeast(0),
+		},
+	},
+	Description: `A violation of this rule occurs whenever the code contains two or more blank lines in a row.`,
+})
+
+// configureCodeMustNotContainMultipleBlankLinesInARow allows up to
+// "maximum-blank-lines" blank lines in a row.
+func configureCodeMustNotContainMultipleBlankLinesInARow(rule *csfmt.Rule, options csfmt.Options) {
+	maximum := options.Int("maximum-blank-lines")
+	rule.Apply = func(source []byte) []byte {
+		return removeBlankLines(source, maximum)
+	}
 }
 
-func applyCodeMustNotContainMultipleBlankLinesInARow(source []byte) []byte {
-	re := regexp.MustCompile("\n{3,}")
-	for re.Match(source) {
-		source = re.ReplaceAllLiteral(source, []byte("\n"))
+// removeBlankLines cuts every run of blank lines longer than the maximum
+// down to the maximum. Line breaks within literals, such as verbatim
+// strings, are never counted.
+func removeBlankLines(source []byte, maximum int) []byte {
+	removed := make([]byte, 0, len(source))
+	breaks := []lexer.Token{}
+	flush := func() {
+		if len(breaks) > maximum+1 {
+			breaks = breaks[:maximum+1]
+		}
+		for _, token := range breaks {
+			removed = append(removed, token.Text...)
+		}
+		breaks = breaks[:0]
+	}
+	for _, token := range lexer.Lex(source) {
+		if token.Kind == lexer.Newline {
+			breaks = append(breaks, token)
+			continue
+		}
+		flush()
+		removed = append(removed, token.Text...)
 	}
-	return source
+	flush()
+	return removed
 }
@@ -3,6 +3,8 @@ package rules
 import (
 	"bytes"
 	"testing"
+
+	"github.com/revolvingcow/csfmt"
 )
 
 func TestCodeMustNotContainMultipleBlankLinesInARow(t *testing.T) {
@@ -24,14 +26,64 @@ func TestCodeMustNotContainMultipleBlankLinesInARow(t *testing.T) {
 			expected: []byte(
 				"public void FunctionName(string s, int i)\n" +
 					"{\n" +
+					"\n" +
 					"    return s + i.ToString();\n" +
 					"}\n"),
 		},
+		{
+			description: "keep blank lines within a verbatim string",
+			given:       []byte("class A { string s = @\"a\n\n\nb\"; }"),
+			expected:    []byte("class A { string s = @\"a\n\n\nb\"; }"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual := codeMustNotContainMultipleBlankLinesInARow.Apply(test.given)
+			if !bytes.Equal(test.expected, actual) {
+				t.Errorf("Got `%s` but wanted `%s`", string(actual), string(test.expected))
+			}
+		})
+	}
+}
+
+func TestCodeMustNotContainMultipleBlankLinesInARowWithOptions(t *testing.T) {
+	tests := []struct {
+		description string
+		options     csfmt.Options
+		given       []byte
+		expected    []byte
+	}{
+		{
+			description: "allow more blank lines",
+			options:     csfmt.Options{"maximum-blank-lines": 2},
+			given:       []byte("{\n\n\n    return;\n}\n"),
+			expected:    []byte("{\n\n\n    return;\n}\n"),
+		},
+		{
+			description: "too many blank lines",
+			options:     csfmt.Options{"maximum-blank-lines": 2},
+			given:       []byte("{\n\n\n\n    return;\n}\n"),
+			expected:    []byte("{\n\n\n    return;\n}\n"),
+		},
+		{
+			description: "no blank lines at all",
+			options:     csfmt.Options{"maximum-blank-lines": 0},
+			given:       []byte("{\n\n    return;\n}\n"),
+			expected:    []byte("{\n    return;\n}\n"),
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
-			actual := applyCodeMustNotContainMultipleBlankLinesInARow(test.given)
+			rule, err := codeMustNotContainMultipleBlankLinesInARow.With(test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := rule.Format("", test.given)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if !bytes.Equal(test.expected, actual) {
 				t.Errorf("Got `%s` but wanted `%s`", string(actual), string(test.expected))
 			}
@@ -1,26 +1,25 @@
 package rules
 
 import (
-	"bytes"
 	"regexp"
 
 	"github.com/revolvingcow/csfmt"
 )
 
 var codeMustNotContainMultipleWhitespaceInARow = &csfmt.Rule{
+	ID:          "CodeMustNotContainMultipleWhitespaceInARow",
+	Code:        "SA1025",
 	Name:        "Code must not contain multiple whitespaces in a row",
 	Enabled:     true,
 	Apply:       applyCodeMustNotContainMultipleWhitespaceInARow,
-	Description: `A violation of this rule occurs whenver the code contains a tab character.`,
+	Description: `A violation of this rule occurs whenever the code contains multiple whitespace characters in a row.`,
 }
 
 func applyCodeMustNotContainMultipleWhitespaceInARow(source []byte) []byte {
-	return scan(source, func(line, literal []byte) []byte {
+	return scan(source, func(line []byte) []byte {
 		re := regexp.MustCompile(`(\S)[ ]{2,}(\S)`)
-		if !bytes.Contains(literal, re.Find(line)) {
-			for re.Match(line) {
-				line = re.ReplaceAll(line, []byte("$1 $2"))
-			}
+		for re.Match(line) {
+			line = re.ReplaceAll(line, []byte("$1 $2"))
 		}
 		return line
 	})
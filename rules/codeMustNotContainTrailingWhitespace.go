@@ -0,0 +1,41 @@
+package rules
+
+import (
+	"bytes"
+	"unicode"
+
+	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/lexer"
+)
+
+var codeMustNotContainTrailingWhitespace = &csfmt.Rule{
+	ID:          "CodeMustNotContainTrailingWhitespace",
+	Code:        "SA1028",
+	Name:        "Code must not contain trailing whitespace",
+	Enabled:     true,
+	Edit:        editCodeMustNotContainTrailingWhitespace,
+	Description: `A violation of this rule occurs when a line of code ends with whitespace.`,
+}
+
+func editCodeMustNotContainTrailingWhitespace(file *csfmt.File) []csfmt.Edit {
+	edits := []csfmt.Edit{}
+	for i, token := range file.Tokens {
+		if !endOfLine(file.Tokens, i+1) {
+			continue
+		}
+
+		switch {
+		case token.Kind == lexer.Whitespace:
+			if edit, ok := replace(file, i, i+1, ""); ok {
+				edits = append(edits, edit)
+			}
+		case token.Kind == lexer.Comment || (token.Kind == lexer.DocComment && bytes.HasPrefix(token.Text, []byte("///"))):
+			// Line comments run to the end of the line, whitespace included
+			text := bytes.TrimRightFunc(token.Text, unicode.IsSpace)
+			if len(text) < len(token.Text) {
+				edits = append(edits, csfmt.Edit{Start: token.Offset + len(text), End: token.End()})
+			}
+		}
+	}
+	return edits
+}
@@ -0,0 +1,52 @@
+package rules
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCodeMustNotContainTrailingWhitespace(t *testing.T) {
+	tests := []struct {
+		description string
+		given       []byte
+		expected    []byte
+	}{
+		{
+			description: "remove trailing spaces",
+			given:       []byte("int a;  \nint b;\t\n"),
+			expected:    []byte("int a;\nint b;\n"),
+		},
+		{
+			description: "remove whitespace on a blank line",
+			given:       []byte("{\n    \n}\n"),
+			expected:    []byte("{\n\n}\n"),
+		},
+		{
+			description: "remove whitespace ending comments",
+			given:       []byte("int a; // a  \n/// <b/>\t\n/* c */ \n"),
+			expected:    []byte("int a; // a\n/// <b/>\n/* c */\n"),
+		},
+		{
+			description: "remove whitespace ending the file",
+			given:       []byte("int a; "),
+			expected:    []byte("int a;"),
+		},
+		{
+			description: "keep whitespace within a verbatim string",
+			given:       []byte("var s = @\"a  \nb\";\n"),
+			expected:    []byte("var s = @\"a  \nb\";\n"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual, err := codeMustNotContainTrailingWhitespace.Format("", test.given)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(test.expected, actual) {
+				t.Errorf("Got `%q` but wanted `%q`", actual, test.expected)
+			}
+		})
+	}
+}
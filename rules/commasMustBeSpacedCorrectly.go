@@ -1,42 +1,46 @@
 package rules
 
 import (
-	"bytes"
-	"regexp"
-
 	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/lexer"
 )
 
 var commasMustBeSpacedCorrectly = &csfmt.Rule{
+	ID:          "CommasMustBeSpacedCorrectly",
+	Code:        "SA1001",
 	Name:        "Commas must be spaced correctly",
 	Enabled:     true,
-	Apply:       applyCommasMustBeSpacedCorrectly,
-	Description: ``,
+	Edit:        editCommasMustBeSpacedCorrectly,
+	Description: `A violation of this rule occurs when a comma is preceded by whitespace or not followed by a single space.`,
 }
 
-func applyCommasMustBeSpacedCorrectly(source []byte) []byte {
-	// Look for leading spaces
-	re := regexp.MustCompile(`(\n)*[\s]+,`)
-	for re.Match(source) {
-		source = re.ReplaceAllLiteral(source, []byte(","))
-	}
+func editCommasMustBeSpacedCorrectly(file *csfmt.File) []csfmt.Edit {
+	edits := []csfmt.Edit{}
+	tokens := file.Tokens
+	for i, token := range tokens {
+		if !token.Is(lexer.Punctuation, ",") {
+			continue
+		}
 
-	return scan(source, func(line, literal []byte) []byte {
-		// Add trailing spaces as necessary
-		re = regexp.MustCompile(`(\S),(\w|\d)`)
-		for re.Match(line) {
-			if !bytes.Contains(literal, re.Find(line)) {
-				line = re.ReplaceAll(line, []byte("$1, $2"))
-			}
+		// Remove leading spaces, even across lines
+		if edit, ok := replace(file, spaceBefore(tokens, i), i, ""); ok {
+			edits = append(edits, edit)
 		}
 
-		// Look for too many trailing spaces
-		re = regexp.MustCompile(`\,  `)
-		for re.Match(line) {
-			if !bytes.Contains(literal, re.Find(line)) {
-				line = re.ReplaceAll(line, []byte(", "))
-			}
+		// A single trailing space unless closing a dimension or generic,
+		// leaving the end of the line to SA1028
+		after := spaceAfter(tokens, i)
+		space := " "
+		if endOfLine(tokens, after) {
+			continue
+		} else if isAny(tokens, after, ",", "]", ">", ")") {
+			space = ""
+		} else if tokens[after].Kind.IsComment() && after > i+1 {
+			continue
 		}
-		return line
-	})
+		if edit, ok := replace(file, i+1, after, space); ok {
+			edits = append(edits, edit)
+		}
+	}
+	return edits
 }
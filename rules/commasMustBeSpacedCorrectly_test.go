@@ -35,11 +35,24 @@ func TestCommasMustBeSpacedCorrectly(t *testing.T) {
 					"	};\n" +
 					"}"),
 		},
+		{
+			description: "ignore every string on the line",
+			given:       []byte(`string.Format("{0},{1}", a ,b, "x ,y");`),
+			expected:    []byte(`string.Format("{0},{1}", a, b, "x ,y");`),
+		},
+		{
+			description: "no space within unbound generics and multidimensional arrays",
+			given:       []byte(`typeof(Dictionary<, >); new int[ , ];`),
+			expected:    []byte(`typeof(Dictionary<,>); new int[,];`),
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
-			actual := applyCommasMustBeSpacedCorrectly(test.given)
+			actual, err := commasMustBeSpacedCorrectly.Format("", test.given)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if !bytes.Equal(test.expected, actual) {
 				t.Errorf("Got `%s` but wanted `%s`", string(actual), string(test.expected))
 			}
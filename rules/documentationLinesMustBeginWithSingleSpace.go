@@ -4,19 +4,27 @@ import (
 	"regexp"
 
 	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/lexer"
 )
 
 var documentationLinesMustBeginWithSingleSpace = &csfmt.Rule{
+	ID:          "DocumentationLinesMustBeginWithSingleSpace",
+	Code:        "SA1004",
 	Name:        "Documentation lines must begin with a single space",
 	Enabled:     true,
 	Apply:       applyDocumentationLinesMustBeginWithSingleSpace,
-	Description: ``,
+	Description: `A violation of this rule occurs when a line within a documentation header does not begin with a single space.`,
 }
 
 func applyDocumentationLinesMustBeginWithSingleSpace(source []byte) []byte {
-	re := regexp.MustCompile(`([/]{3})(\S)`)
-	for re.Match(source) {
-		source = re.ReplaceAll(source, []byte("$1 $2"))
+	re := regexp.MustCompile(`^(///)(\S)`)
+	applied := make([]byte, 0, len(source))
+	for _, token := range lexer.Lex(source) {
+		if token.Kind == lexer.DocComment {
+			applied = append(applied, re.ReplaceAll(token.Text, []byte("$1 $2"))...)
+			continue
+		}
+		applied = append(applied, token.Text...)
 	}
-	return source
+	return applied
 }
@@ -15,6 +15,7 @@ func TestDocumentationLinesMustBeginWithSingleSpace(t *testing.T) {
 		{description: "missing space between comment and text", given: []byte("///The summary."), expected: []byte("/// The summary.")},
 		{description: "missing space between comment and closing XML", given: []byte("///</summary>"), expected: []byte("/// </summary>")},
 		{description: "do nothing if okay", given: []byte("/// <param name=\"foo\">The foo.</param>"), expected: []byte("/// <param name=\"foo\">The foo.</param>")},
+		{description: "within a string", given: []byte("var s = \"///x\";"), expected: []byte("var s = \"///x\";")},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,98 @@
+package rules
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/config"
+)
+
+// editorConfigSpacing lists the .editorconfig properties describing the
+// same spacing as rules in the library, along with the value each rule
+// agrees with.
+var editorConfigSpacing = []struct {
+	property string
+	agrees   string
+	rules    []*csfmt.Rule
+}{
+	{"csharp_space_after_comma", "true", []*csfmt.Rule{commasMustBeSpacedCorrectly}},
+	{"csharp_space_before_comma", "false", []*csfmt.Rule{commasMustBeSpacedCorrectly}},
+	{"csharp_space_after_semicolon_in_for_statement", "true", []*csfmt.Rule{semicolonsMustBeSpacedCorrectly}},
+	{"csharp_space_before_semicolon_in_for_statement", "false", []*csfmt.Rule{semicolonsMustBeSpacedCorrectly}},
+	{"csharp_space_around_binary_operators", "before_and_after", []*csfmt.Rule{symbolsMustBeSpacedCorrectly}},
+	{"csharp_space_after_keywords_in_control_flow_statements", "true", []*csfmt.Rule{openingParenthesisMustBeSpacedCorrectly}},
+	{"csharp_space_between_parentheses", "false", []*csfmt.Rule{openingParenthesisMustBeSpacedCorrectly, closingParenthesisMustBeSpacedCorrectly}},
+	{"csharp_space_before_open_square_brackets", "false", []*csfmt.Rule{openingSquareBracketsMustBeSpacedCorrectly}},
+	{"csharp_space_between_square_brackets", "false", []*csfmt.Rule{openingSquareBracketsMustBeSpacedCorrectly, closingSquareBracketsMustBeSpacedCorrectly}},
+}
+
+// EditorConfig translates the .editorconfig properties in effect for a file
+// into the configuration of the matching rules. Rules are only ever turned
+// off when the project disagrees with them, never on.
+func EditorConfig(properties map[string]string) *config.Config {
+	c := &config.Config{Rules: map[string]config.Rule{}}
+	disable := func(rule *csfmt.Rule) {
+		setting := c.Rules[rule.ID]
+		off := false
+		setting.Enabled = &off
+		c.Rules[rule.ID] = setting
+	}
+	option := func(rule *csfmt.Rule, name string, value interface{}) {
+		setting := c.Rules[rule.ID]
+		if setting.Options == nil {
+			setting.Options = csfmt.Options{}
+		}
+		setting.Options[name] = value
+		c.Rules[rule.ID] = setting
+	}
+
+	if editorConfigValue(properties, "indent_style") == "tab" {
+		disable(tabsMustNotBeUsed)
+	}
+	if width, err := strconv.Atoi(editorConfigValue(properties, "tab_width")); err == nil && width > 0 {
+		option(tabsMustNotBeUsed, "tab-width", width)
+	}
+
+	if value, err := strconv.ParseBool(editorConfigValue(properties, "dotnet_sort_system_directives_first")); err == nil {
+		option(usingDirectivesMustBeOrderedAlphabeticallyByNamespace, "system-first", value)
+	}
+
+	if value := editorConfigValue(properties, "end_of_line"); value == "lf" || value == "crlf" {
+		option(lineEndingsMustBeConsistent, "end-of-line", value)
+	}
+	if encoding, err := csfmt.ParseEncoding(editorConfigValue(properties, "charset")); err == nil {
+		c.Charset = encoding
+	}
+	if value, err := strconv.ParseBool(editorConfigValue(properties, "insert_final_newline")); err == nil {
+		finalNewline := "omit"
+		if value {
+			finalNewline = "require"
+		}
+		option(codeMustNotContainBlankLinesAtEndOfFile, "final-newline", finalNewline)
+	}
+	if value, err := strconv.ParseBool(editorConfigValue(properties, "trim_trailing_whitespace")); err == nil && !value {
+		disable(codeMustNotContainTrailingWhitespace)
+	}
+
+	for _, spacing := range editorConfigSpacing {
+		value := editorConfigValue(properties, spacing.property)
+		if value == "" || value == "ignore" || value == spacing.agrees {
+			continue
+		}
+		for _, rule := range spacing.rules {
+			disable(rule)
+		}
+	}
+	return c
+}
+
+// editorConfigValue returns the value of a property in lower case, leaving
+// out the severity Visual Studio allows to follow it.
+func editorConfigValue(properties map[string]string, name string) string {
+	value := properties[name]
+	if colon := strings.IndexByte(value, ':'); colon >= 0 {
+		value = value[:colon]
+	}
+	return strings.ToLower(strings.TrimSpace(value))
+}
@@ -0,0 +1,111 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/config"
+)
+
+func TestEditorConfig(t *testing.T) {
+	off := false
+	tests := []struct {
+		description string
+		given       map[string]string
+		expected    map[string]config.Rule
+	}{
+		{
+			description: "nothing to say",
+			given:       map[string]string{"charset": "latin1", "csharp_new_line_before_open_brace": "all"},
+			expected:    map[string]config.Rule{},
+		},
+		{
+			description: "indenting with spaces",
+			given:       map[string]string{"indent_style": "space", "indent_size": "2", "tab_width": "2"},
+			expected: map[string]config.Rule{
+				"TabsMustNotBeUsed": {Options: csfmt.Options{"tab-width": 2}},
+			},
+		},
+		{
+			description: "indenting with tabs",
+			given:       map[string]string{"indent_style": "Tab", "indent_size": "tab"},
+			expected: map[string]config.Rule{
+				"TabsMustNotBeUsed": {Enabled: &off},
+			},
+		},
+		{
+			description: "system directives first",
+			given:       map[string]string{"dotnet_sort_system_directives_first": "true:suggestion"},
+			expected: map[string]config.Rule{
+				"UsingDirectivesMustBeOrderedAlphabeticallyByNamespace": {Options: csfmt.Options{"system-first": true}},
+			},
+		},
+		{
+			description: "line endings",
+			given:       map[string]string{"end_of_line": "CRLF"},
+			expected: map[string]config.Rule{
+				"LineEndingsMustBeConsistent": {Options: csfmt.Options{"end-of-line": "crlf"}},
+			},
+		},
+		{
+			description: "final newline",
+			given:       map[string]string{"insert_final_newline": "false"},
+			expected: map[string]config.Rule{
+				"CodeMustNotContainBlankLinesAtEndOfFile": {Options: csfmt.Options{"final-newline": "omit"}},
+			},
+		},
+		{
+			description: "keep trailing whitespace",
+			given:       map[string]string{"trim_trailing_whitespace": "false"},
+			expected: map[string]config.Rule{
+				"CodeMustNotContainTrailingWhitespace": {Enabled: &off},
+			},
+		},
+		{
+			description: "trim trailing whitespace",
+			given:       map[string]string{"trim_trailing_whitespace": "true"},
+			expected:    map[string]config.Rule{},
+		},
+		{
+			description: "unsupported line endings",
+			given:       map[string]string{"end_of_line": "cr"},
+			expected:    map[string]config.Rule{},
+		},
+		{
+			description: "agreeing spacing",
+			given: map[string]string{
+				"csharp_space_after_comma":             "true",
+				"csharp_space_between_parentheses":     "false",
+				"csharp_space_around_binary_operators": "ignore",
+			},
+			expected: map[string]config.Rule{},
+		},
+		{
+			description: "disagreeing spacing",
+			given: map[string]string{
+				"csharp_space_before_comma":        "true",
+				"csharp_space_between_parentheses": "control_flow_statements, expressions",
+			},
+			expected: map[string]config.Rule{
+				"CommasMustBeSpacedCorrectly":             {Enabled: &off},
+				"OpeningParenthesisMustBeSpacedCorrectly": {Enabled: &off},
+				"ClosingParenthesisMustBeSpacedCorrectly": {Enabled: &off},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual := EditorConfig(test.given).Rules
+			if !reflect.DeepEqual(test.expected, actual) {
+				t.Errorf("Got `%v` but wanted `%v`", actual, test.expected)
+			}
+		})
+	}
+	for given, expected := range map[string]csfmt.Encoding{"UTF-16LE": csfmt.UTF16LE, "latin1": ""} {
+		if actual := EditorConfig(map[string]string{"charset": given}).Charset; actual != expected {
+			t.Errorf("Got `%s` for `%s` but wanted `%s`", actual, given, expected)
+		}
+	}
+}
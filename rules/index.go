@@ -1,11 +1,17 @@
 package rules
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/config"
 )
 
 var Library = []*csfmt.Rule{
 	codeMustNotContainMultipleBlankLinesInARow,
+	codeMustNotContainBlankLinesAtStartOfFile,
+	codeMustNotContainBlankLinesAtEndOfFile,
 	usingDirectivesMustBeOrderedAlphabeticallyByNamespace,
 	symbolsMustBeSpacedCorrectly,
 	commasMustBeSpacedCorrectly,
@@ -19,14 +25,86 @@ var Library = []*csfmt.Rule{
 	closingSquareBracketsMustBeSpacedCorrectly,
 	codeMustNotContainMultipleWhitespaceInARow,
 	tabsMustNotBeUsed,
+	codeMustNotContainTrailingWhitespace,
+	lineEndingsMustBeConsistent,
 }
 
-func Enabled() []*csfmt.Rule {
+// Enabled returns the rules to apply under the given configuration, each
+// adjusted to its options. Rules the configuration says nothing about fall
+// back to their own default.
+func Enabled(c *config.Config) ([]*csfmt.Rule, error) {
 	enabled := []*csfmt.Rule{}
 	for _, rule := range Library {
-		if rule.Enabled {
-			enabled = append(enabled, rule)
+		setting := c.Rule(rule.ID)
+		on := rule.Enabled
+		if setting.Enabled != nil {
+			on = *setting.Enabled
+		}
+		if !on {
+			continue
+		}
+
+		configured, err := rule.With(setting.Options)
+		if err != nil {
+			return nil, err
+		}
+		enabled = append(enabled, configured)
+	}
+	return enabled, nil
+}
+
+// configured sets the rule up with the defaults of its options, so applying
+// it without any configuration matches configuring it with them.
+func configured(rule *csfmt.Rule) *csfmt.Rule {
+	rule.Configure(rule, rule.Defaults())
+	return rule
+}
+
+// Lookup finds a rule by its identifier, code or name, ignoring case.
+func Lookup(name string) *csfmt.Rule {
+	for _, rule := range Library {
+		if strings.EqualFold(rule.ID, name) || strings.EqualFold(rule.Name, name) ||
+			(rule.Code != "" && strings.EqualFold(rule.Code, name)) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Validate makes sure every rule in the library can be told apart from the
+// others, knows how to apply itself and has sound options.
+func Validate(library []*csfmt.Rule) error {
+	seen := map[string]bool{}
+	for _, rule := range library {
+		if rule.ID == "" {
+			return fmt.Errorf("rule %q has no identifier", rule.Name)
+		}
+		if rule.Apply == nil && rule.Edit == nil {
+			return fmt.Errorf("rule %s has nothing to apply", rule.ID)
+		}
+		if len(rule.Options) > 0 && rule.Configure == nil {
+			return fmt.Errorf("rule %s has options but cannot be configured", rule.ID)
+		}
+		for _, option := range rule.Options {
+			if _, err := option.Parse(option.Default); err != nil {
+				return fmt.Errorf("rule %s has an invalid default: %s", rule.ID, err)
+			}
+		}
+
+		keys := map[string]string{
+			"identifier": strings.ToLower(rule.ID),
+			"name":       strings.ToLower(rule.Name),
+			"code":       strings.ToLower(rule.Code),
+		}
+		for kind, key := range keys {
+			if key == "" {
+				continue
+			}
+			if seen[kind+":"+key] {
+				return fmt.Errorf("duplicate rule %s %q", kind, key)
+			}
+			seen[kind+":"+key] = true
 		}
 	}
-	return enabled
+	return nil
 }
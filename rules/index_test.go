@@ -0,0 +1,88 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/revolvingcow/csfmt"
+)
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"TabsMustNotBeUsed", "tabsmustnotbeused", "SA1027", "sa1027", "Tabs must not be used", "tabs must not be used"} {
+		if rule := Lookup(name); rule != tabsMustNotBeUsed {
+			t.Errorf("Got `%v` for `%s` but wanted the tabs rule", rule, name)
+		}
+	}
+	if rule := Lookup("Tabs"); rule != nil {
+		t.Errorf("Got `%v` but wanted nothing", rule.ID)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(Library); err != nil {
+		t.Fatal(err)
+	}
+
+	apply := func(source []byte) []byte { return source }
+	configure := func(rule *csfmt.Rule, options csfmt.Options) {}
+	tests := []struct {
+		description string
+		given       []*csfmt.Rule
+	}{
+		{description: "missing identifier", given: []*csfmt.Rule{{Name: "A", Apply: apply}}},
+		{description: "nothing to apply", given: []*csfmt.Rule{{ID: "A", Name: "A"}}},
+		{description: "duplicate identifier", given: []*csfmt.Rule{{ID: "A", Name: "A", Apply: apply}, {ID: "a", Name: "B", Apply: apply}}},
+		{description: "duplicate name", given: []*csfmt.Rule{{ID: "A", Name: "A", Apply: apply}, {ID: "B", Name: "A", Apply: apply}}},
+		{description: "duplicate code", given: []*csfmt.Rule{{ID: "A", Code: "SA1", Name: "A", Apply: apply}, {ID: "B", Code: "SA1", Name: "B", Apply: apply}}},
+		{description: "options without configure", given: []*csfmt.Rule{{ID: "A", Name: "A", Apply: apply, Options: []csfmt.Option{{Name: "a", Type: csfmt.OptionBool, Default: false}}}}},
+		{description: "invalid default", given: []*csfmt.Rule{{ID: "A", Name: "A", Apply: apply, Configure: configure, Options: []csfmt.Option{{Name: "a", Type: csfmt.OptionInt, Default: "four"}}}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if err := Validate(test.given); err == nil {
+				t.Errorf("Got no error but wanted one")
+			}
+		})
+	}
+}
+
+func TestLibraryKeepsEndsOfFile(t *testing.T) {
+	for _, given := range []string{"\nclass A\n{\n}\n", "class A\n{\n}", "class A\r\n{\r\n}\r\n\r\n"} {
+		for _, rule := range Library {
+			if rule == codeMustNotContainBlankLinesAtStartOfFile || rule == codeMustNotContainBlankLinesAtEndOfFile {
+				continue
+			}
+			actual, err := rule.Format("", []byte(given))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(actual) != given {
+				t.Errorf("Got `%q` from %s but wanted `%q`", actual, rule.ID, given)
+			}
+		}
+	}
+}
+
+func TestLibraryDefaults(t *testing.T) {
+	given := []byte("using B;\nusing A;\n\n\n\nclass C\n{\n\tvoid D() { if(true) { } }\n}\r\n\n")
+	for _, rule := range Library {
+		if rule.Configure == nil {
+			continue
+		}
+		configured, err := rule.With(rule.Defaults())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := configured.Format("", given)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := rule.Format("", given)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(actual) != string(expected) {
+			t.Errorf("Got `%q` from %s but wanted `%q`", actual, rule.ID, expected)
+		}
+	}
+}
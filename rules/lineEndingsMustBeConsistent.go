@@ -0,0 +1,69 @@
+package rules
+
+import (
+	"github.com/revolvingcow/csfmt"
+)
+
+var lineEndingsMustBeConsistent = configured(&csfmt.Rule{
+	ID:        "LineEndingsMustBeConsistent",
+	Name:      "Line endings must be consistent",
+	Enabled:   true,
+	Configure: configureLineEndingsMustBeConsistent,
+	Options: []csfmt.Option{
+		{
+			Name:        "end-of-line",
+			Description: "line ending to use, or auto for the one each file uses the most",
+			Type:        csfmt.OptionChoice,
+			Choices:     []string{"auto", "lf", "crlf", "native"},
+			Default:     "auto",
+		},
+	},
+	Description: `A violation of this rule occurs when a line ending differs from the others in the file, or from the one configured.`,
+})
+
+// configureLineEndingsMustBeConsistent uses the "end-of-line" line ending
+// for every file.
+func configureLineEndingsMustBeConsistent(rule *csfmt.Rule, options csfmt.Options) {
+	var ending csfmt.LineEnding
+	switch options.Choice("end-of-line") {
+	case "lf":
+		ending = csfmt.LF
+	case "crlf":
+		ending = csfmt.CRLF
+	case "native":
+		ending = csfmt.NativeLineEnding()
+	}
+	rule.Edit = func(file *csfmt.File) []csfmt.Edit {
+		return lineEndingEdits(file, ending)
+	}
+}
+
+// lineEndingEdits changes every line ending outside of a literal which
+// differs from the given one, or from the one the file uses the most when
+// none is given.
+func lineEndingEdits(file *csfmt.File, ending csfmt.LineEnding) []csfmt.Edit {
+	if ending == "" {
+		ending, _ = csfmt.DetectLineEnding(file.Source)
+	}
+
+	edits := []csfmt.Edit{}
+	for _, token := range file.Tokens {
+		if token.Kind.IsLiteral() {
+			continue
+		}
+		for j, c := range token.Text {
+			if c != '\n' {
+				continue
+			}
+			i := token.Offset + j
+			crlf := i > 0 && file.Source[i-1] == '\r'
+			switch {
+			case crlf && ending == csfmt.LF:
+				edits = append(edits, csfmt.Edit{Start: i - 1, End: i, Text: []byte{}})
+			case !crlf && ending == csfmt.CRLF:
+				edits = append(edits, csfmt.Edit{Start: i, End: i, Text: []byte("\r")})
+			}
+		}
+	}
+	return edits
+}
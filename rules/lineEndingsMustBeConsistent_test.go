@@ -0,0 +1,66 @@
+package rules
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/revolvingcow/csfmt"
+)
+
+func TestLineEndingsMustBeConsistent(t *testing.T) {
+	tests := []struct {
+		description string
+		options     csfmt.Options
+		given       []byte
+		expected    []byte
+	}{
+		{
+			description: "keep crlf",
+			given:       []byte("class A\r\n{\r\n}\r\n"),
+			expected:    []byte("class A\r\n{\r\n}\r\n"),
+		},
+		{
+			description: "mixed follows the most used",
+			given:       []byte("class A\r\n{\n}\r\n"),
+			expected:    []byte("class A\r\n{\r\n}\r\n"),
+		},
+		{
+			description: "configured lf",
+			options:     csfmt.Options{"end-of-line": "lf"},
+			given:       []byte("class A\r\n{ // comment\r\n}\n"),
+			expected:    []byte("class A\n{ // comment\n}\n"),
+		},
+		{
+			description: "configured crlf",
+			options:     csfmt.Options{"end-of-line": "CRLF"},
+			given:       []byte("/* a\n b */\nclass A\n"),
+			expected:    []byte("/* a\r\n b */\r\nclass A\r\n"),
+		},
+		{
+			description: "strings left alone",
+			options:     csfmt.Options{"end-of-line": "crlf"},
+			given:       []byte("var s = @\"a\nb\";\n"),
+			expected:    []byte("var s = @\"a\nb\";\r\n"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			rule, err := lineEndingsMustBeConsistent.With(test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := rule.Format("", test.given)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(test.expected, actual) {
+				t.Errorf("Got `%q` but wanted `%q`", actual, test.expected)
+			}
+		})
+	}
+
+	if _, err := lineEndingsMustBeConsistent.With(csfmt.Options{"end-of-line": "cr"}); err == nil {
+		t.Errorf("Got no error for an unknown line ending but wanted one")
+	}
+}
@@ -2,21 +2,45 @@ package rules
 
 import (
 	"regexp"
+	"strings"
 
 	"github.com/revolvingcow/csfmt"
 )
 
-var openingParenthesisMustBeSpacedCorrectly = &csfmt.Rule{
-	Name:        "Opening parenthesis must be spaced correctly",
-	Enabled:     true,
-	Apply:       applyOpeningParenthesisMustBeSpacedCorrectly,
-	Description: ``,
+var openingParenthesisMustBeSpacedCorrectly = configured(&csfmt.Rule{
+	ID:        "OpeningParenthesisMustBeSpacedCorrectly",
+	Code:      "SA1008",
+	Name:      "Opening parenthesis must be spaced correctly",
+	Enabled:   true,
+	Configure: configureOpeningParenthesisMustBeSpacedCorrectly,
+	Options: []csfmt.Option{
+		{
+			Name:        "keywords",
+			Description: "keywords followed by a space before an opening parenthesis",
+			Type:        csfmt.OptionStrings,
+			Default:     []string{"if", "while", "for", "switch", "foreach", "using"},
+		},
+	},
+	Description: `A violation of this rule occurs when the opening parenthesis within a statement is not spaced correctly.`,
+})
+
+// configureOpeningParenthesisMustBeSpacedCorrectly places a space between
+// each of the "keywords" and the parenthesis following it.
+func configureOpeningParenthesisMustBeSpacedCorrectly(rule *csfmt.Rule, options csfmt.Options) {
+	keywords := options.Strings("keywords")
+	rule.Apply = func(source []byte) []byte {
+		return spaceOpeningParenthesis(source, keywords)
+	}
 }
 
-func applyOpeningParenthesisMustBeSpacedCorrectly(source []byte) []byte {
-	spaceBetween := `(if|while|for|switch|foreach|using|\+|\-|\*|/|&|\||\^|=)`
+func spaceOpeningParenthesis(source []byte, keywords []string) []byte {
+	quoted := []string{}
+	for _, keyword := range keywords {
+		quoted = append(quoted, regexp.QuoteMeta(keyword))
+	}
+	spaceBetween := `(` + strings.Join(append(quoted, `\+|\-|\*|/|&|\||\^|=`), "|") + `)`
 
-	return scan(source, func(line, literal []byte) []byte {
+	return scan(source, func(line []byte) []byte {
 		// Remove leading spaces
 		re := regexp.MustCompile(`([\S])(\t| )([\(])`)
 		for re.Match(line) {
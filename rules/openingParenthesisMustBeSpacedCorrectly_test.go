@@ -3,6 +3,8 @@ package rules
 import (
 	"bytes"
 	"testing"
+
+	"github.com/revolvingcow/csfmt"
 )
 
 func TestOpeningParenthesisMustBeSpacedCorrectly(t *testing.T) {
@@ -21,7 +23,45 @@ func TestOpeningParenthesisMustBeSpacedCorrectly(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
-			actual := applyOpeningParenthesisMustBeSpacedCorrectly(test.given)
+			actual := openingParenthesisMustBeSpacedCorrectly.Apply(test.given)
+			if !bytes.Equal(test.expected, actual) {
+				t.Errorf("Got `%s` but wanted `%s`", string(actual), string(test.expected))
+			}
+		})
+	}
+}
+
+func TestOpeningParenthesisMustBeSpacedCorrectlyWithOptions(t *testing.T) {
+	tests := []struct {
+		description string
+		options     csfmt.Options
+		given       []byte
+		expected    []byte
+	}{
+		{
+			description: "configured keyword",
+			options:     csfmt.Options{"keywords": []string{"lock"}},
+			given:       []byte("lock(this) {}"),
+			expected:    []byte("lock (this) {}"),
+		},
+		{
+			description: "keyword no longer configured",
+			options:     csfmt.Options{"keywords": []string{"lock"}},
+			given:       []byte("if(true) {}"),
+			expected:    []byte("if(true) {}"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			rule, err := openingParenthesisMustBeSpacedCorrectly.With(test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := rule.Format("", test.given)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if !bytes.Equal(test.expected, actual) {
 				t.Errorf("Got `%s` but wanted `%s`", string(actual), string(test.expected))
 			}
@@ -1,30 +1,27 @@
 package rules
 
 import (
-	"bytes"
 	"regexp"
 
 	"github.com/revolvingcow/csfmt"
 )
 
 var openingSquareBracketsMustBeSpacedCorrectly = &csfmt.Rule{
+	ID:          "OpeningSquareBracketsMustBeSpacedCorrectly",
+	Code:        "SA1010",
 	Name:        "Opening square brackets must be spaced correctly",
 	Enabled:     true,
 	Apply:       applyOpeningSquareBracketsMustBeSpacedCorrectly,
-	Description: ``,
+	Description: `A violation of this rule occurs when an opening square bracket within a statement is not spaced correctly.`,
 }
 
 func applyOpeningSquareBracketsMustBeSpacedCorrectly(source []byte) []byte {
-	return scan(source, func(line, literal []byte) []byte {
+	return scan(source, func(line []byte) []byte {
 		re := regexp.MustCompile(`([\S])([\t ]+)([\[])`)
-		if !bytes.Contains(literal, re.Find(line)) {
-			line = re.ReplaceAll(line, []byte("$1$3"))
-		}
+		line = re.ReplaceAll(line, []byte("$1$3"))
 
 		re = regexp.MustCompile(`([\[])([\t ]+)([\S])`)
-		if !bytes.Contains(literal, re.Find(line)) {
-			line = re.ReplaceAll(line, []byte("$1$3"))
-		}
+		line = re.ReplaceAll(line, []byte("$1$3"))
 
 		return line
 	})
@@ -1,29 +1,32 @@
 package rules
 
 import (
-	"bytes"
 	"regexp"
 
 	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/lexer"
 )
 
 var preprocessorKeywordsMustNotBePrecededBySpace = &csfmt.Rule{
-	Name:        "Single line comments must begin with single space",
+	ID:          "PreprocessorKeywordsMustNotBePrecededBySpace",
+	Code:        "SA1006",
+	Name:        "Preprocessor keywords must not be preceded by space",
 	Enabled:     true,
 	Apply:       applyPreprocessorKeywordsMustNotBePrecededBySpace,
-	Description: ``,
+	Description: `A violation of this rule occurs when the keyword of a preprocessor directive is preceded by space.`,
 }
 
 func applyPreprocessorKeywordsMustNotBePrecededBySpace(source []byte) []byte {
 	keywords := `(if|else|elif|endif|define|undef|warning|error|line|region|endregion|pragma|pragma warning|pragma checksum)`
-	re := regexp.MustCompile(`([#])(\t| )+` + keywords)
+	re := regexp.MustCompile(`\A([#])(\t| )+` + keywords)
 
-	return scan(source, func(line, literal []byte) []byte {
-		for re.Match(line) {
-			if !bytes.Contains(literal, re.Find(line)) {
-				line = re.ReplaceAll(line, []byte("$1$3"))
-			}
+	formatted := []byte{}
+	for _, token := range lexer.Lex(source) {
+		text := token.Text
+		if token.Kind == lexer.Preprocessor {
+			text = re.ReplaceAll(text, []byte("$1$3"))
 		}
-		return line
-	})
+		formatted = append(formatted, text...)
+	}
+	return formatted
 }
@@ -3,48 +3,134 @@ package rules
 import (
 	"bufio"
 	"bytes"
-	"regexp"
 	"unicode"
+	"unicode/utf8"
+
+	"github.com/revolvingcow/csfmt/lexer"
 )
 
-func scan(source []byte, applyFunc func(line, literal []byte) []byte) []byte {
-	reCommentShortBegin := regexp.MustCompile(`\A\s*(/{2,})`)
-	reCommentLongBegin := regexp.MustCompile(`\A\s*(/\*)`)
-	reCommentLongEnd := regexp.MustCompile(`\*/`)
-	reString := regexp.MustCompile(`".*"`)
+// Placeholders are drawn from the supplementary private use areas so they
+// never match the word or symbol classes the rules look for.
+var placeholderRanges = [][2]rune{
+	{0xF0000, 0xFFFFD},
+	{0x100000, 0x10FFFD},
+}
+
+// protected reports whether a token's bytes are not code and must never be
+// rewritten by a line based rule.
+func protected(token lexer.Token) bool {
+	return token.Kind.IsComment() || token.Kind.IsLiteral() || token.Kind == lexer.Preprocessor
+}
+
+// mask replaces each line of every comment, literal and preprocessor
+// directive with a single placeholder rune. The returned function reverses
+// the substitution on any text derived from the masked source.
+func mask(source []byte) ([]byte, func([]byte) []byte) {
+	// Never hand out a placeholder the source already uses
+	used := map[rune]bool{}
+	for _, r := range string(source) {
+		if r >= placeholderRanges[0][0] {
+			used[r] = true
+		}
+	}
+
+	originals := map[rune][]byte{}
+	current, bound := placeholderRanges[0][0], 0
+	placeholder := func(original []byte) ([]byte, bool) {
+		for used[current] {
+			current++
+		}
+		if current > placeholderRanges[bound][1] {
+			if bound++; bound >= len(placeholderRanges) {
+				return original, false
+			}
+			current = placeholderRanges[bound][0]
+		}
+		originals[current] = original
+		p := make([]byte, utf8.RuneLen(current))
+		utf8.EncodeRune(p, current)
+		current++
+		return p, true
+	}
+
+	masked := make([]byte, 0, len(source))
+	for _, token := range lexer.Lex(source) {
+		if !protected(token) {
+			masked = append(masked, token.Text...)
+			continue
+		}
+
+		// Keep line breaks intact so the line structure is unchanged
+		text := token.Text
+		for len(text) > 0 {
+			end := bytes.IndexAny(text, "\r\n")
+			if end < 0 {
+				end = len(text)
+			}
+			if end > 0 {
+				p, _ := placeholder(text[:end])
+				masked = append(masked, p...)
+			}
+			text = text[end:]
+			for len(text) > 0 && (text[0] == '\r' || text[0] == '\n') {
+				masked = append(masked, text[0])
+				text = text[1:]
+			}
+		}
+	}
+
+	restore := func(text []byte) []byte {
+		if len(originals) == 0 {
+			return text
+		}
+
+		restored := make([]byte, 0, len(text))
+		for i := 0; i < len(text); {
+			r, size := utf8.DecodeRune(text[i:])
+			if original, ok := originals[r]; ok {
+				restored = append(restored, original...)
+			} else {
+				restored = append(restored, text[i:i+size]...)
+			}
+			i += size
+		}
+		return restored
+	}
+
+	return masked, restore
+}
+
+// scan applies a function to each line of the source. Comments, string and
+// character literals and preprocessor directives are masked out beforehand
+// so the function only ever sees code.
+func scan(source []byte, applyFunc func(line []byte) []byte) []byte {
+	masked, restore := mask(source)
 
-	short := false
-	long := false
 	lines := []byte{}
-	buffer := bytes.NewBuffer(source)
+	buffer := bytes.NewBuffer(masked)
 	scanner := bufio.NewScanner(buffer)
+	scanner.Buffer(nil, len(masked)+1)
 
-	for scanner.Scan() {
+	for i := 0; scanner.Scan(); i++ {
 		// Add a newline character on each line after the first
-		if len(lines) > 0 {
+		if i > 0 {
 			lines = append(lines, byte('\n'))
 		}
 
+		// Keep the function from growing the code over the line's ending
 		line := scanner.Bytes()
-		short = reCommentShortBegin.Match(line)
-		long = long || reCommentLongBegin.Match(line)
-
-		literal := []byte{}
-		if reString.Match(line) {
-			literal = reString.Find(line)
-		}
+		code := bytes.TrimRightFunc(line, unicode.IsSpace)
+		ending := line[len(code):]
+		code = applyFunc(code[:len(code):len(code)])
 
-		if !(short || long) {
-			line = applyFunc(line, literal)
-
-			// Trim ending
-			line = bytes.TrimRightFunc(line, unicode.IsSpace)
-		}
+		lines = append(lines, bytes.TrimRightFunc(code, unicode.IsSpace)...)
+		lines = append(lines, ending...)
+	}
 
-		short = false
-		long = long && !reCommentLongEnd.Match(line)
-		lines = append(lines, line...)
+	// The scanner leaves out the line break ending the file
+	if bytes.HasSuffix(masked, []byte("\n")) {
+		lines = append(lines, byte('\n'))
 	}
 
-	return lines
+	return restore(lines)
 }
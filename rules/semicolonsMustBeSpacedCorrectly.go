@@ -1,36 +1,44 @@
 package rules
 
 import (
-	"bytes"
-	"regexp"
-
 	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/lexer"
 )
 
 var semicolonsMustBeSpacedCorrectly = &csfmt.Rule{
+	ID:          "SemicolonsMustBeSpacedCorrectly",
+	Code:        "SA1002",
 	Name:        "Semicolons must be spaced correctly",
 	Enabled:     true,
-	Apply:       applySemicolonsMustBeSpacedCorrectly,
-	Description: ``,
+	Edit:        editSemicolonsMustBeSpacedCorrectly,
+	Description: `A violation of this rule occurs when a semicolon is preceded by whitespace or not followed by a single space.`,
 }
 
-func applySemicolonsMustBeSpacedCorrectly(source []byte) []byte {
-	return scan(source, func(line, literal []byte) []byte {
-		// Look for leading spaces
-		re := regexp.MustCompile(`\s;`)
-		for re.Match(line) {
-			if !bytes.Contains(literal, re.Find(line)) {
-				line = re.ReplaceAllLiteral(line, []byte(";"))
-			}
+func editSemicolonsMustBeSpacedCorrectly(file *csfmt.File) []csfmt.Edit {
+	edits := []csfmt.Edit{}
+	tokens := file.Tokens
+	for i, token := range tokens {
+		if !token.Is(lexer.Punctuation, ";") {
+			continue
 		}
 
-		// Add trailing spaces as necessary
-		re = regexp.MustCompile(`;(\S)`)
-		for re.Match(line) {
-			if !bytes.Contains(literal, re.Find(line)) {
-				line = re.ReplaceAll(line, []byte("; $1"))
+		// Look for leading spaces, allowing for empty for loop clauses
+		before := spaceBefore(tokens, i)
+		if !isAny(tokens, before-1, ";", "(") {
+			if edit, ok := replace(file, before, i, ""); ok {
+				edits = append(edits, edit)
 			}
 		}
-		return line
-	})
+
+		// Add trailing spaces as necessary, leaving the end of the line to
+		// SA1028
+		after := spaceAfter(tokens, i)
+		if endOfLine(tokens, after) || isAny(tokens, after, ";", ")") || (tokens[after].Kind.IsComment() && after > i+1) {
+			continue
+		}
+		if edit, ok := replace(file, i+1, after, " "); ok {
+			edits = append(edits, edit)
+		}
+	}
+	return edits
 }
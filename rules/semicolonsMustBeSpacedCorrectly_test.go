@@ -14,12 +14,18 @@ func TestSemicolonsMustBeSpacedCorrectly(t *testing.T) {
 		{description: "when none are found", given: []byte("public void FunctionName(string s, int i)"), expected: []byte("public void FunctionName(string s, int i)")},
 		{description: "with inline comment", given: []byte("var i = 0;// blah"), expected: []byte("var i = 0; // blah")},
 		{description: "with no trailing space", given: []byte("for (i = 0;i < 4;i++) {"), expected: []byte("for (i = 0; i < 4; i++) {")},
-		{description: "with leading space and trailing space", given: []byte("return s + i.ToString() ; "), expected: []byte("return s + i.ToString();")},
+		{description: "with leading space and trailing space", given: []byte("return s + i.ToString() ; "), expected: []byte("return s + i.ToString(); ")},
+		{description: "ignore character literals", given: []byte("var c = ';' ;"), expected: []byte("var c = ';';")},
+		{description: "empty for loop clauses", given: []byte("for (;;) {}\nfor (int i = 0; ; i++) {}"), expected: []byte("for (;;) {}\nfor (int i = 0; ; i++) {}")},
+		{description: "ignore block comments opening mid line", given: []byte("i++; /* a ;b\n c;d */"), expected: []byte("i++; /* a ;b\n c;d */")},
 	}
 
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
-			actual := applySemicolonsMustBeSpacedCorrectly(test.given)
+			actual, err := semicolonsMustBeSpacedCorrectly.Format("", test.given)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if !bytes.Equal(test.expected, actual) {
 				t.Errorf("Got `%s` but wanted `%s`", string(actual), string(test.expected))
 			}
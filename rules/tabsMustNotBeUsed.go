@@ -1,22 +1,48 @@
 package rules
 
 import (
-	"regexp"
+	"bytes"
 
 	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/lexer"
 )
 
-var tabsMustNotBeUsed = &csfmt.Rule{
-	Name:        "Tabs must not be used",
-	Enabled:     true,
-	Apply:       applyTabsMustNotBeUsed,
-	Description: `A violation of this rule occurs whenver the code contains a tab character.`,
+var tabsMustNotBeUsed = configured(&csfmt.Rule{
+	ID:        "TabsMustNotBeUsed",
+	Code:      "SA1027",
+	Name:      "Tabs must not be used",
+	Enabled:   true,
+	Configure: configureTabsMustNotBeUsed,
+	Options: []csfmt.Option{
+		{
+			Name:        "tab-width",
+			Description: "number of spaces replacing each tab",
+			Type:        csfmt.OptionInt,
+			Default:     4,
+			Validate:    csfmt.AtLeast(1),
+		},
+	},
+	Description: `A violation of this rule occurs whenever the code contains a tab character.`,
+})
+
+// configureTabsMustNotBeUsed replaces each tab with "tab-width" spaces.
+func configureTabsMustNotBeUsed(rule *csfmt.Rule, options csfmt.Options) {
+	width := options.Int("tab-width")
+	rule.Apply = func(source []byte) []byte {
+		return expandTabs(source, width)
+	}
 }
 
-func applyTabsMustNotBeUsed(source []byte) []byte {
-	re := regexp.MustCompile(`\t`)
-	for re.Match(source) {
-		source = re.ReplaceAllLiteral(source, []byte("    "))
+// expandTabs replaces each tab outside of literals with width spaces.
+func expandTabs(source []byte, width int) []byte {
+	spaces := bytes.Repeat([]byte(" "), width)
+	expanded := make([]byte, 0, len(source))
+	for _, token := range lexer.Lex(source) {
+		if token.Kind.IsLiteral() || token.Kind == lexer.Preprocessor {
+			expanded = append(expanded, token.Text...)
+			continue
+		}
+		expanded = append(expanded, bytes.Replace(token.Text, []byte("\t"), spaces, -1)...)
 	}
-	return source
+	return expanded
 }
@@ -3,6 +3,8 @@ package rules
 import (
 	"bytes"
 	"testing"
+
+	"github.com/revolvingcow/csfmt"
 )
 
 func TestTabsMustNotBeUsed(t *testing.T) {
@@ -16,14 +18,63 @@ func TestTabsMustNotBeUsed(t *testing.T) {
 			given:       []byte("public void FunctionName(string s, int i)\n{\n\tvar i = 0; // blah\n\tfor (i = 0; i < 4; i++) {\n\t\t// Do something\n\t}\n\treturn s + i.ToString();\n}"),
 			expected:    []byte("public void FunctionName(string s, int i)\n{\n    var i = 0; // blah\n    for (i = 0; i < 4; i++) {\n        // Do something\n    }\n    return s + i.ToString();\n}"),
 		},
+		{
+			description: "keep tabs within strings",
+			given:       []byte("{\n\tvar s = \"a\tb\";\n\tvar c = '\t';\n}"),
+			expected:    []byte("{\n    var s = \"a\tb\";\n    var c = '\t';\n}"),
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
-			actual := applyTabsMustNotBeUsed(test.given)
+			actual := tabsMustNotBeUsed.Apply(test.given)
 			if !bytes.Equal(test.expected, actual) {
 				t.Errorf("Got `%s` but wanted `%s`", string(actual), string(test.expected))
 			}
 		})
 	}
 }
+
+func TestTabsMustNotBeUsedWithOptions(t *testing.T) {
+	tests := []struct {
+		description string
+		options     csfmt.Options
+		given       []byte
+		expected    []byte
+	}{
+		{
+			description: "default tab width",
+			options:     csfmt.Options{},
+			given:       []byte("{\n\treturn;\n}"),
+			expected:    []byte("{\n    return;\n}"),
+		},
+		{
+			description: "configured tab width",
+			options:     csfmt.Options{"tab-width": 2.0},
+			given:       []byte("{\n\t\treturn;\n}"),
+			expected:    []byte("{\n    return;\n}"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			rule, err := tabsMustNotBeUsed.With(test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := rule.Format("", test.given)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(test.expected, actual) {
+				t.Errorf("Got `%s` but wanted `%s`", string(actual), string(test.expected))
+			}
+		})
+	}
+
+	for _, invalid := range []csfmt.Options{{"tab-width": 0}, {"tab-width": 2.5}, {"tab-width": "four"}} {
+		if _, err := tabsMustNotBeUsed.With(invalid); err == nil {
+			t.Errorf("Got no error for `%v` but wanted one", invalid)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package rules
+
+import (
+	"github.com/revolvingcow/csfmt"
+	"github.com/revolvingcow/csfmt/lexer"
+)
+
+// spaceBefore returns the index of the first whitespace or newline token
+// directly preceding the token at i. When the run is preceded by anything
+// other than code, such as a comment, the index of the token itself is
+// returned since removing the run would change more than spacing.
+func spaceBefore(tokens []lexer.Token, i int) int {
+	j := i
+	for j > 0 && (tokens[j-1].Kind == lexer.Whitespace || tokens[j-1].Kind == lexer.Newline) {
+		j--
+	}
+	if j == 0 || tokens[j-1].Kind.IsTrivia() || tokens[j-1].Kind == lexer.Preprocessor {
+		return i
+	}
+	return j
+}
+
+// spaceAfter returns the index just past the whitespace token, if any,
+// directly following the token at i.
+func spaceAfter(tokens []lexer.Token, i int) int {
+	if i+1 < len(tokens) && tokens[i+1].Kind == lexer.Whitespace {
+		return i + 2
+	}
+	return i + 1
+}
+
+// endOfLine reports whether the token at i is a line break or beyond the
+// last token.
+func endOfLine(tokens []lexer.Token, i int) bool {
+	return i >= len(tokens) || tokens[i].Kind == lexer.Newline
+}
+
+// replace creates an edit swapping the tokens from up to (but excluding) to
+// with the text. When the range is empty the text is inserted before the
+// token at from.
+func replace(file *csfmt.File, from, to int, text string) (csfmt.Edit, bool) {
+	start := len(file.Source)
+	if from < len(file.Tokens) {
+		start = file.Tokens[from].Offset
+	}
+	end := start
+	if to > from {
+		end = file.Tokens[to-1].End()
+	}
+
+	if string(file.Source[start:end]) == text {
+		return csfmt.Edit{}, false
+	}
+	return csfmt.Edit{Start: start, End: end, Text: []byte(text)}, true
+}
+
+// isAny reports whether the token at i is punctuation matching any of the
+// given symbols.
+func isAny(tokens []lexer.Token, i int, symbols ...string) bool {
+	if i < 0 || i >= len(tokens) || tokens[i].Kind != lexer.Punctuation {
+		return false
+	}
+	for _, symbol := range symbols {
+		if string(tokens[i].Text) == symbol {
+			return true
+		}
+	}
+	return false
+}
@@ -1,44 +1,114 @@
 package rules
 
 import (
-	"fmt"
-	"regexp"
+	"bytes"
 	"sort"
 	"strings"
+	"unicode"
 
 	"github.com/revolvingcow/csfmt"
 )
 
-var usingDirectivesMustBeOrderedAlphabeticallyByNamespace = &csfmt.Rule{
-	Name:        "Using directives must be ordered alphabetically by namespace",
-	Enabled:     true,
-	Apply:       applyUsingDirectivesMustBeOrderedAlphabeticallyByNamespace,
-	Description: ``,
+var usingDirectivesMustBeOrderedAlphabeticallyByNamespace = configured(&csfmt.Rule{
+	ID:        "UsingDirectivesMustBeOrderedAlphabeticallyByNamespace",
+	Code:      "SA1210",
+	Name:      "Using directives must be ordered alphabetically by namespace",
+	Enabled:   true,
+	Configure: configureUsingDirectivesMustBeOrderedAlphabeticallyByNamespace,
+	Options: []csfmt.Option{
+		{
+			Name:        "system-first",
+			Description: "place System namespaces before all others",
+			Type:        csfmt.OptionBool,
+			Default:     false,
+		},
+	},
+	Description: `A violation of this rule occurs when the using directives are not sorted alphabetically by namespace.`,
+})
+
+// configureUsingDirectivesMustBeOrderedAlphabeticallyByNamespace places the
+// System namespaces before all others when "system-first" is set.
+func configureUsingDirectivesMustBeOrderedAlphabeticallyByNamespace(rule *csfmt.Rule, options csfmt.Options) {
+	systemFirst := options.Bool("system-first")
+	rule.Apply = func(source []byte) []byte {
+		return sortUsings(source, systemFirst)
+	}
 }
 
-func applyUsingDirectivesMustBeOrderedAlphabeticallyByNamespace(source []byte) []byte {
-	usings := []string{}
-	source = scan(source, func(line, literal []byte) []byte {
-		// Find usings
-		re := regexp.MustCompile(`(.*)(using)([\t| ])([^\(])(.*)([;])`)
-		if re.Match(line) {
-			using := re.ReplaceAll(line, []byte("$2 $4$5"))
-			usings = append(usings, string(using))
-			line = []byte{}
+// sortUsings sorts the using directives found on lines of their own among
+// the others sharing their scope.
+func sortUsings(source []byte, systemFirst bool) []byte {
+	scopes := map[string][]usingLine{}
+	order := []string{}
+	for _, directive := range csfmt.UsingDirectives(source) {
+		line, ok := usingLineOf(source, directive)
+		if !ok {
+			continue
 		}
+		if _, ok := scopes[directive.Scope]; !ok {
+			order = append(order, directive.Scope)
+		}
+		scopes[directive.Scope] = append(scopes[directive.Scope], line)
+	}
 
-		return line
-	})
+	edits := []csfmt.Edit{}
+	for _, scope := range order {
+		lines := scopes[scope]
+		sorted := append([]usingLine{}, lines...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if systemFirst && isSystem(sorted[i].using) != isSystem(sorted[j].using) {
+				return isSystem(sorted[i].using)
+			}
+			return sorted[i].using < sorted[j].using
+		})
 
-	if len(usings) > 0 {
-		// Sort the usings and add them to the top of the file
-		s := sort.StringSlice(usings)
-		if !sort.IsSorted(s) {
-			sort.Sort(s)
+		// Each directive takes the place of another so none leaves its scope
+		for i, line := range lines {
+			edits = append(edits, csfmt.Edit{Start: line.start, End: line.end, Text: source[sorted[i].start:sorted[i].end]})
 		}
+	}
+
+	sorted, err := csfmt.ApplyEdits(source, edits)
+	if err != nil {
+		return source
+	}
+	return sorted
+}
+
+// usingLine is a using directive on a line of its own, along with any
+// comment following it on the same line.
+type usingLine struct {
+	start int
+	end   int
+	using string
+}
 
-		source = append([]byte(fmt.Sprintf("%s;\n\n", strings.Join(s, ";\n"))), source...)
+// usingLineOf returns the line of the directive unless it shares the line
+// with other code or spans several.
+func usingLineOf(source []byte, directive csfmt.UsingDirective) (usingLine, bool) {
+	lineStart := bytes.LastIndexByte(source[:directive.Start], '\n') + 1
+	lineEnd := len(source)
+	if end := bytes.IndexByte(source[directive.End:], '\n'); end >= 0 {
+		lineEnd = directive.End + end
 	}
 
-	return source
+	before := bytes.TrimSpace(source[lineStart:directive.Start])
+	after := bytes.TrimSpace(source[directive.End:lineEnd])
+	if len(before) > 0 || (len(after) > 0 && !bytes.HasPrefix(after, []byte("//"))) ||
+		bytes.IndexByte(source[directive.Start:directive.End], '\n') >= 0 {
+		return usingLine{}, false
+	}
+
+	return usingLine{
+		start: directive.Start,
+		end:   directive.End + len(bytes.TrimRightFunc(source[directive.End:lineEnd], unicode.IsSpace)),
+		using: strings.Join(strings.Fields(string(source[directive.Start:directive.End-1])), " "),
+	}, true
+}
+
+// isSystem reports whether the using directive refers to the System
+// namespace or one nested within it.
+func isSystem(using string) bool {
+	namespace := strings.TrimPrefix(using, "using ")
+	return namespace == "System" || strings.HasPrefix(namespace, "System.")
 }
@@ -3,6 +3,8 @@ package rules
 import (
 	"bytes"
 	"testing"
+
+	"github.com/revolvingcow/csfmt"
 )
 
 func TestUsingDirectivesMustBeOrderedAlphabeticallyByNamespace(t *testing.T) {
@@ -53,11 +55,132 @@ func TestUsingDirectivesMustBeOrderedAlphabeticallyByNamespace(t *testing.T) {
 					"namespace Company.Blah {}\n" +
 					"using (var something = new Something()) {}"),
 		},
+		{
+			description: "sort within each namespace",
+			given: []byte(
+				"using Z;\n" +
+					"using System;\n" +
+					"\n" +
+					"namespace A\n" +
+					"{\n" +
+					"    using D; // for D\n" +
+					"    using B = C.D;\n" +
+					"\n" +
+					"    class E { }\n" +
+					"}"),
+			expected: []byte(
+				"using System;\n" +
+					"using Z;\n" +
+					"\n" +
+					"namespace A\n" +
+					"{\n" +
+					"    using B = C.D;\n" +
+					"    using D; // for D\n" +
+					"\n" +
+					"    class E { }\n" +
+					"}"),
+		},
+		{
+			description: "sort within each preprocessor region",
+			given: []byte(
+				"using Z;\n" +
+					"#if DEBUG\n" +
+					"using Y;\n" +
+					"using X;\n" +
+					"#endif\n" +
+					"using B;\n" +
+					"class C { }"),
+			expected: []byte(
+				"using B;\n" +
+					"#if DEBUG\n" +
+					"using X;\n" +
+					"using Y;\n" +
+					"#endif\n" +
+					"using Z;\n" +
+					"class C { }"),
+		},
+		{
+			description: "leave using declarations alone",
+			given: []byte(
+				"using B;\n" +
+					"using A;\n" +
+					"class C\n" +
+					"{\n" +
+					"    void D()\n" +
+					"    {\n" +
+					"        using var a = E();\n" +
+					"    }\n" +
+					"}"),
+			expected: []byte(
+				"using A;\n" +
+					"using B;\n" +
+					"class C\n" +
+					"{\n" +
+					"    void D()\n" +
+					"    {\n" +
+					"        using var a = E();\n" +
+					"    }\n" +
+					"}"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual := usingDirectivesMustBeOrderedAlphabeticallyByNamespace.Apply(test.given)
+			if !bytes.Equal(test.expected, actual) {
+				t.Errorf("Got `%s` but wanted `%s`", string(actual), string(test.expected))
+			}
+		})
+	}
+}
+
+func TestUsingDirectivesMustBeOrderedAlphabeticallyByNamespaceWithOptions(t *testing.T) {
+	given := []byte(
+		"using Microsoft.Extensions;\n" +
+			"using System.Linq;\n" +
+			"using Systematic;\n" +
+			"using System;\n" +
+			"\n" +
+			"namespace Company.Blah {}")
+	tests := []struct {
+		description string
+		options     csfmt.Options
+		expected    []byte
+	}{
+		{
+			description: "alphabetically",
+			options:     csfmt.Options{"system-first": false},
+			expected: []byte(
+				"using Microsoft.Extensions;\n" +
+					"using System;\n" +
+					"using System.Linq;\n" +
+					"using Systematic;\n" +
+					"\n" +
+					"namespace Company.Blah {}"),
+		},
+		{
+			description: "system first",
+			options:     csfmt.Options{"system-first": true},
+			expected: []byte(
+				"using System;\n" +
+					"using System.Linq;\n" +
+					"using Microsoft.Extensions;\n" +
+					"using Systematic;\n" +
+					"\n" +
+					"namespace Company.Blah {}"),
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
-			actual := applyUsingDirectivesMustBeOrderedAlphabeticallyByNamespace(test.given)
+			rule, err := usingDirectivesMustBeOrderedAlphabeticallyByNamespace.With(test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := rule.Format("", given)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if !bytes.Equal(test.expected, actual) {
 				t.Errorf("Got `%s` but wanted `%s`", string(actual), string(test.expected))
 			}
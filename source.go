@@ -1,19 +1,31 @@
 package csfmt
 
 import (
+	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/revolvingcow/csfmt/lexer"
 )
 
 var (
 	extensions = []string{".cs"}
+
+	// generatedSuffixes end the names of files written by tools
+	generatedSuffixes = []string{".designer.cs", ".g.cs", ".g.i.cs", ".generated.cs", ".assemblyinfo.cs", ".assemblyattributes.cs"}
 )
 
 // SourceFile represents a file declared as source code.
 type SourceFile struct {
-	Path string
+	Path     string
+	Encoding Encoding
+
+	modified time.Time
+	size     int64
 }
 
 func (f *SourceFile) Exists() bool {
@@ -39,32 +51,81 @@ func (f *SourceFile) IsDotNet() bool {
 	return false
 }
 
-// Read the file contents.
+// Read the file contents decoded to UTF-8, remembering the encoding they
+// were found in.
 func (f *SourceFile) Read() ([]byte, error) {
-	contents, err := ioutil.ReadFile(f.Path)
+	fi, err := os.Open(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer fi.Close()
+
+	info, err := fi.Stat()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := ioutil.ReadAll(fi)
 	if err != nil {
 		return nil, err
 	}
+
+	contents, encoding, err := Decode(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", f.Path, err)
+	}
+	f.Encoding = encoding
+	f.modified, f.size = info.ModTime(), info.Size()
 	return contents, nil
 }
 
-// Write the contents out to a stream.
+// Write the contents out to a stream in the encoding of the file, replacing
+// the file as a whole. Nothing is written when the file changed since it
+// was read.
 func (f *SourceFile) Write(contents []byte) error {
-	fi, err := os.OpenFile(f.Path, os.O_TRUNC|os.O_WRONLY, 0666)
+	encoded, err := Encode(contents, f.Encoding)
+	if err != nil {
+		return fmt.Errorf("%s: %s", f.Path, err)
+	}
+
+	path, err := filepath.EvalSymlinks(f.Path)
 	if err != nil {
 		return err
 	}
-	defer fi.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !f.modified.IsZero() && (!info.ModTime().Equal(f.modified) || info.Size() != f.size) {
+		return fmt.Errorf("%s: changed since it was read", f.Path)
+	}
 
-	_, err = fi.Write(contents)
+	temp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
 	if err != nil {
 		return err
 	}
-	return nil
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(encoded); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Chmod(info.Mode()); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
 }
 
-// Walk a directory's file structure looking for source files.
-func (f *SourceFile) Walk() chan SourceFile {
+// Walk a directory's file structure looking for source files, leaving out
+// the paths ignored reports. It may be left nil.
+func (f *SourceFile) Walk(ignored func(path string, isDir bool) bool) chan SourceFile {
 	c := make(chan SourceFile)
 
 	go func() {
@@ -73,6 +134,13 @@ func (f *SourceFile) Walk() chan SourceFile {
 				return e
 			}
 
+			if ignored != nil && ignored(p, fi.IsDir()) {
+				if fi.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
 			s := SourceFile{
 				Path: p,
 			}
@@ -89,3 +157,34 @@ func (f *SourceFile) Walk() chan SourceFile {
 
 	return c
 }
+
+// HasGeneratedName reports whether the file is named the way tools name the
+// source they generate.
+func (f *SourceFile) HasGeneratedName() bool {
+	name := strings.ToLower(filepath.Base(f.Path))
+	if strings.HasPrefix(name, "temporarygeneratedfile_") {
+		return true
+	}
+	for _, suffix := range generatedSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasGeneratedHeader reports whether the comments heading the source say it
+// was generated by a tool.
+func HasGeneratedHeader(source []byte) bool {
+	for _, token := range lexer.Lex(source) {
+		if !token.Kind.IsTrivia() {
+			break
+		}
+		comment := bytes.ToLower(token.Text)
+		if token.Kind.IsComment() && (bytes.Contains(comment, []byte("<auto-generated")) ||
+			bytes.Contains(comment, []byte("<autogenerated"))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package csfmt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csfmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "A.cs")
+	if err := ioutil.WriteFile(path, []byte("\xEF\xBB\xBFclass A{}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := SourceFile{Path: path}
+	if _, err := s.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write([]byte("class A {}\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(written) != "\xEF\xBB\xBFclass A {}\n" {
+		t.Errorf("Got `%q` but wanted the byte order mark kept", written)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Got `%s` but wanted the permissions kept", info.Mode())
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("Got %d files but wanted the temporary file gone", len(files))
+	}
+
+	// Somebody else changes the file after it was read
+	if _, err := s.Read(); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := ioutil.WriteFile(path, []byte("class B {}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write([]byte("class A {}\n")); err == nil {
+		t.Errorf("Got no error but wanted the changed file left alone")
+	}
+	if written, _ := ioutil.ReadFile(path); string(written) != "class B {}\n" {
+		t.Errorf("Got `%q` but wanted the changed file left alone", written)
+	}
+}
+
+func TestGenerated(t *testing.T) {
+	tests := []struct {
+		description string
+		path        string
+		source      string
+		expected    bool
+	}{
+		{description: "hand written", path: "Form.cs", source: "// Form\nclass Form {}\n"},
+		{description: "designer", path: filepath.Join("src", "Form.Designer.cs"), expected: true},
+		{description: "source generator", path: "Views.g.i.cs", expected: true},
+		{description: "build assembly info", path: "App.AssemblyInfo.cs", expected: true},
+		{description: "project assembly info", path: "AssemblyInfo.cs"},
+		{description: "header", path: "Client.cs", source: "\ufeff//------\r\n// <auto-generated>\r\n// </auto-generated>\r\nclass Client {}", expected: true},
+		{description: "old header", path: "Client.cs", source: "/* <autogenerated /> */ class Client {}", expected: true},
+		{description: "header after code", path: "Client.cs", source: "class Client {}\n// <auto-generated/>\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			s := SourceFile{Path: test.path}
+			actual := s.HasGeneratedName() || HasGeneratedHeader([]byte(test.source))
+			if actual != test.expected {
+				t.Errorf("Got `%t` but wanted `%t`", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestWalk(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csfmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"A.cs", "B.txt", filepath.Join("src", "C.cs"), filepath.Join("obj", "D.cs")} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("class A {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	visited := map[string]bool{}
+	ignored := func(path string, isDir bool) bool {
+		rel, _ := filepath.Rel(dir, path)
+		visited[rel] = true
+		return rel == "obj"
+	}
+
+	root := SourceFile{Path: dir}
+	found := []string{}
+	for s := range root.Walk(ignored) {
+		rel, _ := filepath.Rel(dir, s.Path)
+		found = append(found, rel)
+	}
+	if expected := []string{"A.cs", filepath.Join("src", "C.cs")}; !reflect.DeepEqual(expected, found) {
+		t.Errorf("Got `%v` but wanted `%v`", found, expected)
+	}
+	if visited[filepath.Join("obj", "D.cs")] {
+		t.Errorf("Got the ignored directory walked")
+	}
+}
@@ -0,0 +1,286 @@
+package csfmt
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/revolvingcow/csfmt/lexer"
+)
+
+// Suppression turns a rule off from Start up to End of the source. A
+// suppression without a rule turns off every rule.
+type Suppression struct {
+	Rule  string
+	Start int
+	End   int
+}
+
+// Suppresses reports whether the suppression turns off the rule. StyleCop
+// names rules as the code and identifier joined by a colon so either will
+// do.
+func (s Suppression) Suppresses(r *Rule) bool {
+	if s.Rule == "" {
+		return true
+	}
+	for _, name := range strings.Split(s.Rule, ":") {
+		name = strings.TrimSpace(name)
+		if strings.EqualFold(name, r.ID) || (r.Code != "" && strings.EqualFold(name, r.Code)) {
+			return true
+		}
+	}
+	return false
+}
+
+var suppressionComment = regexp.MustCompile(`^//\s*csfmt:(disable-next-line|disable|enable)\b(.*)$`)
+
+// Suppressions finds the parts of the source where rules are turned off.
+func Suppressions(source []byte) []Suppression {
+	if !bytes.Contains(source, []byte("csfmt:")) && !bytes.Contains(source, []byte("pragma")) &&
+		!bytes.Contains(source, []byte("SuppressMessage")) {
+		return nil
+	}
+
+	suppressions := []Suppression{}
+
+	// Where each rule was turned off
+	disabled := map[string]int{}
+	disable := func(names []string, at int) {
+		if len(names) == 0 {
+			names = []string{""}
+		}
+		for _, name := range names {
+			if _, ok := disabled[name]; !ok {
+				disabled[name] = at
+			}
+		}
+	}
+	enable := func(names []string, at int) {
+		if len(names) == 0 {
+			for name := range disabled {
+				names = append(names, name)
+			}
+		}
+		for _, name := range names {
+			if start, ok := disabled[name]; ok {
+				suppressions = append(suppressions, Suppression{Rule: name, Start: start, End: at})
+				delete(disabled, name)
+			}
+		}
+	}
+
+	tokens := lexer.Lex(source)
+	for i, token := range tokens {
+		text := string(token.Text)
+		end := token.Offset + len(token.Text)
+		switch token.Kind {
+		case lexer.Comment:
+			match := suppressionComment.FindStringSubmatch(text)
+			if match == nil {
+				continue
+			}
+			names := suppressedRules(match[2])
+			switch match[1] {
+			case "disable":
+				disable(names, token.Offset)
+			case "enable":
+				enable(names, end)
+			case "disable-next-line":
+				start := lineEnd(source, end)
+				if len(names) == 0 {
+					names = []string{""}
+				}
+				for _, name := range names {
+					suppressions = append(suppressions, Suppression{Rule: name, Start: start, End: lineEnd(source, start)})
+				}
+			}
+		case lexer.Preprocessor:
+			if comment := strings.Index(text, "//"); comment >= 0 {
+				text = text[:comment]
+			}
+			words := strings.Fields(strings.TrimPrefix(text, "#"))
+			if len(words) < 3 || words[0] != "pragma" || words[1] != "warning" {
+				continue
+			}
+			names := suppressedRules(strings.Join(words[3:], " "))
+			switch words[2] {
+			case "disable":
+				disable(names, token.Offset)
+			case "restore":
+				enable(names, end)
+			}
+		case lexer.Identifier:
+			if suppression, ok := suppressMessage(source, tokens, i); ok {
+				suppressions = append(suppressions, suppression)
+			}
+		}
+	}
+	enable(nil, len(source))
+
+	sort.SliceStable(suppressions, func(i, j int) bool {
+		return suppressions[i].Start < suppressions[j].Start
+	})
+	return suppressions
+}
+
+// suppressedRules splits the names of rules given to a suppression, leaving
+// out any reason.
+func suppressedRules(text string) []string {
+	if reason := strings.Index(text, "--"); reason >= 0 {
+		text = text[:reason]
+	}
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
+// lineEnd returns the offset just past the line break ending the line
+// found at offset.
+func lineEnd(source []byte, offset int) int {
+	if newline := bytes.IndexByte(source[offset:], '\n'); newline >= 0 {
+		return offset + newline + 1
+	}
+	return len(source)
+}
+
+// suppressMessage reads the SuppressMessage attribute named by the token
+// at index i, if it is one, into the suppression it describes.
+func suppressMessage(source []byte, tokens []lexer.Token, i int) (Suppression, bool) {
+	name := string(tokens[i].Text)
+	if name != "SuppressMessage" && name != "SuppressMessageAttribute" {
+		return Suppression{}, false
+	}
+
+	// Code tokens of the source by position, and where the name is amongst them
+	code := []lexer.Token{}
+	at := -1
+	for j, token := range tokens {
+		if j == i {
+			at = len(code)
+		}
+		if !token.Kind.IsTrivia() {
+			code = append(code, token)
+		}
+	}
+
+	// The start of the attribute section holding the attribute
+	open, depth := -1, 0
+	for j := at - 1; j >= 0 && open < 0; j-- {
+		switch string(code[j].Text) {
+		case "]", ")":
+			depth++
+		case "[", "(":
+			if depth == 0 && string(code[j].Text) == "[" {
+				open = j
+			}
+			depth--
+		}
+	}
+	if open < 0 || (open+2 < len(code) && string(code[open+2].Text) == ":") {
+		return Suppression{}, false
+	}
+
+	// The rule is the second argument of the attribute, which ends with the
+	// bracket closing the section
+	rule := ""
+	argument := 0
+	depth = 0
+	j := at + 1
+	for ; j < len(code); j++ {
+		token := code[j]
+		switch string(token.Text) {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case ",":
+			if depth == 1 {
+				argument++
+			}
+		}
+		if depth < 0 {
+			break
+		}
+		if rule == "" && argument == 1 && depth == 1 && (token.Kind == lexer.String || token.Kind == lexer.VerbatimString) {
+			rule = strings.Trim(string(token.Text), `@"`)
+		}
+	}
+	if rule == "" {
+		return Suppression{}, false
+	}
+
+	// The member follows the end of the attribute section
+	end := memberEnd(code, j+1)
+	return Suppression{Rule: rule, Start: lineStart(source, code[open].Offset), End: lineEnd(source, end)}, true
+}
+
+// memberEnd returns the offset just past the end of the member starting
+// with the token at index i.
+func memberEnd(code []lexer.Token, i int) int {
+	depth := 0
+	for ; i < len(code); i++ {
+		text := string(code[i].Text)
+		end := code[i].Offset + len(code[i].Text)
+		switch text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth < 0 {
+				return code[i].Offset
+			}
+			if depth == 0 && text == "}" {
+				return end
+			}
+		case ";", ",":
+			if depth == 0 {
+				return end
+			}
+		}
+	}
+	if len(code) == 0 {
+		return 0
+	}
+	last := code[len(code)-1]
+	return last.Offset + len(last.Text)
+}
+
+// lineStart returns the offset of the start of the line found at offset.
+func lineStart(source []byte, offset int) int {
+	return bytes.LastIndexByte(source[:offset], '\n') + 1
+}
+
+// suppressions returns the parts of the source where the rule is off.
+func (r *Rule) suppressions(source []byte) []Suppression {
+	suppressions := []Suppression{}
+	for _, suppression := range Suppressions(source) {
+		if suppression.Suppresses(r) {
+			suppressions = append(suppressions, suppression)
+		}
+	}
+	return suppressions
+}
+
+// unsuppressed returns the edits which touch none of the suppressions.
+func unsuppressed(edits []Edit, suppressions []Suppression) []Edit {
+	if len(suppressions) == 0 {
+		return edits
+	}
+
+	kept := []Edit{}
+	for _, edit := range edits {
+		suppressed := false
+		for _, suppression := range suppressions {
+			if edit.Start < suppression.End && edit.End >= suppression.Start {
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			kept = append(kept, edit)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,101 @@
+package csfmt
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+)
+
+func TestFormatHonorsSuppressions(t *testing.T) {
+	tabs := &Rule{
+		ID:   "TabsMustNotBeUsed",
+		Code: "SA1027",
+		Apply: func(source []byte) []byte {
+			return bytes.Replace(source, []byte("\t"), []byte(" "), -1)
+		},
+	}
+	sorted := &Rule{
+		ID: "Sorted",
+		Apply: func(source []byte) []byte {
+			lines := bytes.SplitAfter(source, []byte("\n"))
+			sort.Slice(lines, func(i, j int) bool {
+				return bytes.Compare(lines[i], lines[j]) < 0
+			})
+			return bytes.Join(lines, nil)
+		},
+	}
+
+	tests := []struct {
+		description string
+		rule        *Rule
+		given       string
+		expected    string
+	}{
+		{
+			description: "disabled until enabled",
+			rule:        tabs,
+			given:       "a\tb;\n// csfmt:disable TabsMustNotBeUsed\nc\td;\n// csfmt:enable\ne\tf;\n",
+			expected:    "a b;\n// csfmt:disable TabsMustNotBeUsed\nc\td;\n// csfmt:enable\ne f;\n",
+		},
+		{
+			description: "disabled until the end of the file",
+			rule:        tabs,
+			given:       "a\tb;\n//csfmt:disable\nc\td;\n",
+			expected:    "a b;\n//csfmt:disable\nc\td;\n",
+		},
+		{
+			description: "another rule disabled",
+			rule:        tabs,
+			given:       "// csfmt:disable SA1025\na\tb;\n",
+			expected:    "// csfmt:disable SA1025\na b;\n",
+		},
+		{
+			description: "next line",
+			rule:        tabs,
+			given:       "// csfmt:disable-next-line SA1027 -- aligned\na\tb;\nc\td;\n",
+			expected:    "// csfmt:disable-next-line SA1027 -- aligned\na\tb;\nc d;\n",
+		},
+		{
+			description: "pragma",
+			rule:        tabs,
+			given:       "#pragma warning disable CS0168, SA1027 // aligned\na\tb;\n#pragma warning restore SA1027\nc\td;\n",
+			expected:    "#pragma warning disable CS0168, SA1027 // aligned\na\tb;\n#pragma warning restore SA1027\nc d;\n",
+		},
+		{
+			description: "suppress message attribute",
+			rule:        tabs,
+			given:       "class A\n{\n\t[SuppressMessage(\"StyleCop.CSharp.SpacingRules\", \"SA1027:TabsMustNotBeUsed\")]\n\tvoid B() {\n\t}\n\tvoid C() {\n\t}\n}\n",
+			expected:    "class A\n{\n\t[SuppressMessage(\"StyleCop.CSharp.SpacingRules\", \"SA1027:TabsMustNotBeUsed\")]\n\tvoid B() {\n\t}\n void C() {\n }\n}\n",
+		},
+		{
+			description: "suppress message attribute on a field",
+			rule:        tabs,
+			given:       "[Obsolete, SuppressMessage(\"\", \"SA1027\", Justification = \"a\tb\")] int a\t= 1;\nint b\t= 2;\n",
+			expected:    "[Obsolete, SuppressMessage(\"\", \"SA1027\", Justification = \"a\tb\")] int a\t= 1;\nint b = 2;\n",
+		},
+		{
+			description: "assembly suppress message attribute",
+			rule:        tabs,
+			given:       "[assembly: SuppressMessage(\"\", \"SA1027\", Target = \"A\")]\nclass A\t{ }\n",
+			expected:    "[assembly: SuppressMessage(\"\", \"SA1027\", Target = \"A\")]\nclass A { }\n",
+		},
+		{
+			description: "edits which only work together",
+			rule:        sorted,
+			given:       "// csfmt:disable-next-line\nb;\na;\n",
+			expected:    "// csfmt:disable-next-line\nb;\na;\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			actual, err := test.rule.Format("", []byte(test.given))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(actual) != test.expected {
+				t.Errorf("Got `%q` but wanted `%q`", actual, test.expected)
+			}
+		})
+	}
+}